@@ -22,6 +22,15 @@ type CreateDBOptions = iface.CreateDBOptions
 // EventUpdated An event sent when the access controller has been updated
 type EventUpdated struct{}
 
+// SubscribeUpdated Returns a channel receiving an event each time the access
+// controller has been updated, it is closed once the context is done
+func SubscribeUpdated(ctx context.Context, emitter events.EmitterInterface) <-chan *EventUpdated {
+	ch := make(chan *EventUpdated)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
 type orbitDBAccessController struct {
 	events.EventEmitter
 	orbitdb iface.OrbitDB
@@ -190,9 +199,9 @@ func (o *orbitDBAccessController) Load(ctx context.Context, address string) erro
 
 	o.kvStore = store
 
-	go o.kvStore.Subscribe(ctx, func(e events.Event) {
+	o.kvStore.Subscribe(ctx, func(e events.Event) {
 		switch e.(type) {
-		case stores.EventReady, stores.EventWrite, stores.EventReplicated:
+		case *stores.EventReady, *stores.EventWrite, *stores.EventReplicated:
 			o.onUpdate()
 		}
 	})
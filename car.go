@@ -0,0 +1,58 @@
+package orbitdb
+
+import (
+	"context"
+	"io"
+	"path"
+
+	ipfslogio "berty.tech/go-ipfs-log/io"
+	"berty.tech/go-orbit-db/utils"
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	"github.com/pkg/errors"
+)
+
+// headsImporter A store able to merge the heads of a CAR file whose blocks
+// have already been imported, as the stores built on basestore
+type headsImporter interface {
+	ImportHeads(ctx context.Context, roots []cid.Cid) error
+}
+
+func (o *orbitDB) ImportCAR(ctx context.Context, r io.Reader, options *CreateDBOptions) (Store, error) {
+	roots, err := utils.ImportCAR(ctx, o.ipfs, r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to import CAR file")
+	}
+
+	if len(roots) == 0 {
+		return nil, errors.New("CAR file has no root")
+	}
+
+	manifestNode, err := ipfslogio.ReadCBOR(ctx, o.ipfs, roots[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch database manifest")
+	}
+
+	manifest := &utils.Manifest{}
+	if err := cbornode.DecodeInto(manifestNode.RawData(), manifest); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal manifest")
+	}
+
+	store, err := o.Open(ctx, path.Join("/orbitdb", roots[0].String(), manifest.Name), options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open store")
+	}
+
+	importer, ok := store.(headsImporter)
+	if !ok {
+		_ = store.Close()
+		return nil, errors.New("store doesn't support importing CAR files")
+	}
+
+	if err := importer.ImportHeads(ctx, roots); err != nil {
+		_ = store.Close()
+		return nil, errors.Wrap(err, "unable to import CAR file in store")
+	}
+
+	return store, nil
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+// SubscribeChan Sends the events emitted by an emitter to a typed channel,
+// ie. a `chan *EventType`. Only the events assignable to the element type of
+// the channel are delivered, they are queued until the channel receiver is
+// ready. The channel is closed once the subscription ends. As for
+// signal.Notify, it panics if ch is not a channel accepting sends.
+func SubscribeChan(ctx context.Context, emitter EmitterInterface, ch interface{}) Subscription {
+	chValue := reflect.ValueOf(ch)
+	if chValue.Kind() != reflect.Chan || chValue.Type().ChanDir()&reflect.SendDir == 0 {
+		panic("events: SubscribeChan requires a channel accepting sends")
+	}
+
+	elemType := chValue.Type().Elem()
+
+	var (
+		lock   sync.Mutex
+		closed bool
+		stop   = make(chan struct{})
+	)
+
+	sub := emitter.SubscribeWithOptions(ctx, func(evt Event) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if closed {
+			return
+		}
+
+		reflect.Select([]reflect.SelectCase{
+			{Dir: reflect.SelectSend, Chan: chValue, Send: reflect.ValueOf(evt)},
+			{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(stop)},
+		})
+	}, &SubscribeOptions{
+		Filter: func(evt Event) bool {
+			return evt != nil && reflect.TypeOf(evt).AssignableTo(elemType)
+		},
+	})
+
+	go func() {
+		<-sub.Done()
+
+		// releases a pending send before closing the channel
+		close(stop)
+
+		lock.Lock()
+		defer lock.Unlock()
+
+		closed = true
+		chValue.Close()
+	}()
+
+	return sub
+}
@@ -2,80 +2,172 @@ package events
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
 )
 
 // Event Is a base interface for events
 type Event interface{}
 
+// DeliveryMode Defines how events are delivered to a subscriber which
+// doesn't keep up with the emitter
+type DeliveryMode int
+
+const (
+	// DeliveryModeQueue Queues the events without bound, Emit never blocks
+	// and no event is lost
+	DeliveryModeQueue DeliveryMode = iota
+
+	// DeliveryModeBlock Blocks Emit until the subscriber buffer has room for
+	// the event, the handler must not wait for the emitter to return
+	DeliveryModeBlock
+
+	// DeliveryModeDrop Drops the events when the subscriber buffer is full,
+	// the dropped events are counted by the subscription
+	DeliveryModeDrop
+)
+
+// defaultBufferSize The buffer size of the subscriptions in block and drop
+// modes when none is given
+const defaultBufferSize = 50
+
+// SubscribeOptions Options given to SubscribeWithOptions
+type SubscribeOptions struct {
+	// Mode The delivery mode of the subscription, queue by default
+	Mode DeliveryMode
+
+	// BufferSize The number of events buffered in block and drop modes
+	BufferSize int
+
+	// Filter Only delivers the events for which it returns true, all the
+	// events are delivered when nil
+	Filter func(Event) bool
+}
+
+// Subscription A handle on the registration of an event handler
+type Subscription interface {
+	// Unsubscribe Stops delivering events to the handler, the pending ones
+	// are discarded
+	Unsubscribe()
+
+	// Dropped Returns the number of events dropped, always 0 unless using
+	// the drop delivery mode
+	Dropped() uint64
+
+	// Done Returns a channel closed once the subscription has ended, either
+	// by unsubscribing or by canceling its context
+	Done() <-chan struct{}
+}
+
 // EmitterInterface Root interface for events dispatch
 type EmitterInterface interface {
 	// Emit Sends an event to the subscribed listeners
 	Emit(Event)
 
-	// Subscribe Registers a callback that will receive emitted events
-	Subscribe(context.Context, func(Event))
+	// Subscribe Registers a callback that will receive emitted events, the
+	// events are queued until the callback is able to process them
+	Subscribe(context.Context, func(Event)) Subscription
+
+	// SubscribeWithOptions Registers a callback that will receive emitted
+	// events, using the given delivery mode and filter
+	SubscribeWithOptions(context.Context, func(Event), *SubscribeOptions) Subscription
 
 	// UnsubscribeAll removes all listeners
 	UnsubscribeAll()
 }
 
 type eventSubscription struct {
-	Chan   chan Event
-	Cancel context.CancelFunc
+	// dropped Is first to be 64-bit aligned for atomic operations
+	dropped uint64
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	options SubscribeOptions
+
+	// ch Holds the pending events in block and drop modes
+	ch chan Event
+
+	// queue Holds the pending events in queue mode, notify is signaled when
+	// it is no longer empty
+	queueLock sync.Mutex
+	queue     []Event
+	notify    chan struct{}
+
+	emitter *EventEmitter
 }
 
-// EventEmitter Registers listeners and dispatches events to them
+// EventEmitter Registers listeners and dispatches events to them, it is safe
+// for concurrent use
 type EventEmitter struct {
 	Subscribers []*eventSubscription
+	lock        sync.RWMutex
 }
 
 func (e *EventEmitter) UnsubscribeAll() {
+	e.lock.Lock()
 	oldSubscribers := e.Subscribers
 	e.Subscribers = nil
+	e.lock.Unlock()
+
 	for _, c := range oldSubscribers {
-		c.Cancel()
+		c.cancel()
 	}
 }
 
 func (e *EventEmitter) Emit(evt Event) {
-	for _, s := range e.Subscribers {
-		select {
-		case s.Chan <- evt:
-			break
-		default:
-			break
-		}
+	e.lock.RLock()
+	subscribers := make([]*eventSubscription, len(e.Subscribers))
+	copy(subscribers, e.Subscribers)
+	e.lock.RUnlock()
+
+	for _, s := range subscribers {
+		s.deliver(evt)
 	}
 }
 
-func (e *EventEmitter) Subscribe(ctx context.Context, handler func(Event)) {
-	ctx, cancelFunc := context.WithCancel(ctx)
+func (e *EventEmitter) Subscribe(ctx context.Context, handler func(Event)) Subscription {
+	return e.SubscribeWithOptions(ctx, handler, nil)
+}
 
-	ch := make(chan Event, 50)
+func (e *EventEmitter) SubscribeWithOptions(ctx context.Context, handler func(Event), options *SubscribeOptions) Subscription {
+	ctx, cancelFunc := context.WithCancel(ctx)
 
 	sub := &eventSubscription{
-		Chan:   ch,
-		Cancel: cancelFunc,
+		ctx:     ctx,
+		cancel:  cancelFunc,
+		emitter: e,
 	}
 
-	e.Subscribers = append(e.Subscribers, sub)
-
-	for {
-		select {
-		case <-ctx.Done():
-			e.unsubscribe(sub)
-			return
+	if options != nil {
+		sub.options = *options
+	}
 
-		case evt := <-ch:
-			handler(evt)
+	if sub.options.Mode == DeliveryModeQueue {
+		sub.notify = make(chan struct{}, 1)
+	} else {
+		if sub.options.BufferSize <= 0 {
+			sub.options.BufferSize = defaultBufferSize
 		}
+
+		sub.ch = make(chan Event, sub.options.BufferSize)
 	}
+
+	e.lock.Lock()
+	e.Subscribers = append(e.Subscribers, sub)
+	e.lock.Unlock()
+
+	go sub.run(handler)
+
+	return sub
 }
 
 func (e *EventEmitter) unsubscribe(c *eventSubscription) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
 	for i, s := range e.Subscribers {
 		if s == c {
-			c.Cancel()
+			c.cancel()
 			e.Subscribers[len(e.Subscribers)-1], e.Subscribers[i] = e.Subscribers[i], e.Subscribers[len(e.Subscribers)-1]
 			e.Subscribers = e.Subscribers[:len(e.Subscribers)-1]
 			return
@@ -83,4 +175,90 @@ func (e *EventEmitter) unsubscribe(c *eventSubscription) {
 	}
 }
 
+// deliver Hands an event to the subscription according to its delivery mode
+func (s *eventSubscription) deliver(evt Event) {
+	if s.ctx.Err() != nil || (s.options.Filter != nil && !s.options.Filter(evt)) {
+		return
+	}
+
+	switch s.options.Mode {
+	case DeliveryModeQueue:
+		s.queueLock.Lock()
+		s.queue = append(s.queue, evt)
+		s.queueLock.Unlock()
+
+		select {
+		case s.notify <- struct{}{}:
+		default:
+		}
+
+	case DeliveryModeBlock:
+		select {
+		case s.ch <- evt:
+		case <-s.ctx.Done():
+		}
+
+	case DeliveryModeDrop:
+		select {
+		case s.ch <- evt:
+		default:
+			atomic.AddUint64(&s.dropped, 1)
+		}
+	}
+}
+
+// run Calls the handler for each delivered event until the subscription is
+// canceled
+func (s *eventSubscription) run(handler func(Event)) {
+	defer s.emitter.unsubscribe(s)
+
+	for {
+		if s.options.Mode != DeliveryModeQueue {
+			select {
+			case <-s.ctx.Done():
+				return
+
+			case evt := <-s.ch:
+				handler(evt)
+			}
+
+			continue
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+
+		case <-s.notify:
+		}
+
+		s.queueLock.Lock()
+		pending := s.queue
+		s.queue = nil
+		s.queueLock.Unlock()
+
+		for _, evt := range pending {
+			if s.ctx.Err() != nil {
+				return
+			}
+
+			handler(evt)
+		}
+	}
+}
+
+func (s *eventSubscription) Unsubscribe() {
+	s.cancel()
+	s.emitter.unsubscribe(s)
+}
+
+func (s *eventSubscription) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+func (s *eventSubscription) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
 var _ EmitterInterface = &EventEmitter{}
+var _ Subscription = &eventSubscription{}
@@ -2,6 +2,8 @@ package iface
 
 import (
 	"context"
+	"io"
+	"time"
 
 	ipfslog "berty.tech/go-ipfs-log"
 	"berty.tech/go-ipfs-log/identityprovider"
@@ -29,6 +31,13 @@ type CreateDBOptions struct {
 	Keystore                *keystore.Keystore
 	Cache                   datastore.Datastore
 	Identity                *identityprovider.Identity
+	SnapshotCompression     *bool
+	ConflictResolver        ConflictResolver
+	PreWriteHooks           []PreWriteHook
+	PostWriteHooks          []OnWritePrototype
+	ValidateHooks           []ValidateHook
+	Schema                  []byte
+	StoreSpecificOpts       interface{}
 }
 
 // DetermineAddressOptions Lists the arguments used to determine a store address
@@ -36,6 +45,7 @@ type DetermineAddressOptions struct {
 	OnlyHash         *bool
 	Replicate        *bool
 	AccessController accesscontroller.ManifestParams
+	Schema           []byte
 }
 
 // OrbitDB Provides the main OrbitDB interface used to open and create stores
@@ -49,12 +59,22 @@ type OrbitDB interface {
 	// Open Opens an existing data store
 	Open(ctx context.Context, dbAddress string, options *CreateDBOptions) (Store, error)
 
+	// ImportCAR Opens the store contained in a CARv1 file written by
+	// Store.ExportCAR, without requiring network access
+	ImportCAR(ctx context.Context, r io.Reader, options *CreateDBOptions) (Store, error)
+
 	// Log Creates or opens an EventLogStore
 	Log(ctx context.Context, address string, options *CreateDBOptions) (EventLogStore, error)
 
 	// KeyValue Creates or opens an KeyValueStore
 	KeyValue(ctx context.Context, address string, options *CreateDBOptions) (KeyValueStore, error)
 
+	// Docs Creates or opens a DocumentStore
+	Docs(ctx context.Context, address string, options *CreateDBOptions) (DocumentStore, error)
+
+	// Counter Creates or opens a CounterStore
+	Counter(ctx context.Context, address string, options *CreateDBOptions) (CounterStore, error)
+
 	// Create Creates a new store
 	Create(ctx context.Context, name string, storeType string, options *CreateDBOptions) (Store, error)
 
@@ -72,6 +92,13 @@ type StreamOptions struct {
 	LT     *cid.Cid
 	LTE    *cid.Cid
 	Amount *int
+
+	// Since Only selects the entries written at or after this time, entries
+	// without a timestamp are ignored when Since or Until is set
+	Since *time.Time
+
+	// Until Only selects the entries written before this time
+	Until *time.Time
 }
 
 // Store Defines the operations common to all stores types
@@ -111,6 +138,17 @@ type Store interface {
 	// LoadFromSnapshot Loads store content from a snapshot
 	LoadFromSnapshot(ctx context.Context) error
 
+	// LoadFromSnapshotCID Loads store content from a snapshot identified by
+	// its CID, ie. a snapshot saved by another peer
+	LoadFromSnapshotCID(ctx context.Context, snapshot cid.Cid) error
+
+	// ExportCAR Writes the store manifests and oplog entries as a CARv1 file
+	ExportCAR(ctx context.Context, w io.Writer) error
+
+	// ImportCAR Adds the blocks of a CARv1 file to IPFS and merges its heads
+	// with the store
+	ImportCAR(ctx context.Context, r io.Reader) error
+
 	// OpLog Returns the underlying IPFS Log instance for the store
 	OpLog() ipfslog.Log
 
@@ -128,6 +166,41 @@ type Store interface {
 
 	// AddOperation Adds an operation to this store
 	AddOperation(ctx context.Context, op operation.Operation, onProgressCallback chan<- ipfslog.Entry) (ipfslog.Entry, error)
+
+	// Diff Returns the entries reachable from the to heads but not from the
+	// from heads, empty from heads select all the entries
+	Diff(ctx context.Context, from []cid.Cid, to []cid.Cid) ([]ipfslog.Entry, error)
+
+	// At Returns a read-only view of the store as it was when the given
+	// entries were the heads of the log
+	At(ctx context.Context, heads []cid.Cid) (ReadOnlyStore, error)
+
+	// AddPreWriteHook Registers a hook called before an operation is
+	// written, it can reject or transform the operation
+	AddPreWriteHook(hook PreWriteHook)
+
+	// AddPostWriteHook Registers a hook called once an entry has been
+	// written and the index updated
+	AddPostWriteHook(hook OnWritePrototype)
+
+	// AddValidateHook Registers a hook checking both the local operations
+	// and the replicated ones before they are added to the oplog
+	AddValidateHook(hook ValidateHook)
+}
+
+// ReadOnlyStore A read-only view of a store, ie. at a past state
+type ReadOnlyStore interface {
+	// Address Returns the address of the viewed store
+	Address() address.Address
+
+	// Index Returns the index built from the entries of the view
+	Index() StoreIndex
+
+	// OpLog Returns the log containing the entries of the view
+	OpLog() ipfslog.Log
+
+	// AddOperation Always fails as a view can't be modified
+	AddOperation(ctx context.Context, op operation.Operation, onProgressCallback chan<- ipfslog.Entry) (ipfslog.Entry, error)
 }
 
 // EventLogStore A type of store that provides an append only log
@@ -137,14 +210,55 @@ type EventLogStore interface {
 	// Add Appends data to the log
 	Add(ctx context.Context, data []byte) (operation.Operation, error)
 
-	// Get Fetches an entry of the log
+	// Get Fetches an entry of the log, eventlogstore.ErrNotFound is returned
+	// when it is not part of the log
 	Get(ctx context.Context, cid cid.Cid) (operation.Operation, error)
 
-	// Stream Populates a chan of entries from this store
+	// Stream Populates a chan of entries from this store, the chan is closed
+	// when done
 	Stream(ctx context.Context, resultChan chan operation.Operation, options *StreamOptions) error
 
 	// List Fetches a list of operation that occurred on this store
 	List(ctx context.Context, options *StreamOptions) ([]operation.Operation, error)
+
+	// Iterator Returns an iterator over the operations of the log, it fails
+	// with eventlogstore.ErrUnknownCursor when the cursor can't be found
+	Iterator(options *EventLogIteratorOptions) (EventLogIterator, error)
+
+	// Follow Returns a chan receiving the operations selected by the options,
+	// then the operations added locally or by replication until the context
+	// is done
+	Follow(ctx context.Context, options *StreamOptions) (<-chan operation.Operation, error)
+}
+
+// EventLogCursor An opaque position in the log of an EventLogStore
+type EventLogCursor string
+
+// EventLogIteratorOptions Defines the parameters that can be given to the
+// Iterator function of an EventLogStore
+type EventLogIteratorOptions struct {
+	// Cursor The position to start after, the iterator starts before the
+	// first operation when nil
+	Cursor *EventLogCursor
+
+	// Reverse Iterates from the latest operation to the oldest one
+	Reverse bool
+}
+
+// EventLogIterator Iterates over the operations of an EventLogStore, the
+// operations added while iterating are visible
+type EventLogIterator interface {
+	// Next Moves to the next operation and returns it, io.EOF is returned
+	// when there is none
+	Next(ctx context.Context) (operation.Operation, error)
+
+	// Prev Moves to the previous operation and returns it, io.EOF is
+	// returned when there is none
+	Prev(ctx context.Context) (operation.Operation, error)
+
+	// Cursor Returns the position of the last returned operation, empty when
+	// the iterator is before the first operation
+	Cursor() EventLogCursor
 }
 
 // EventLogStore A type of store that provides a key value store
@@ -162,6 +276,164 @@ type KeyValueStore interface {
 
 	// Get Retrieves the value for a key of the map
 	Get(ctx context.Context, key string) ([]byte, error)
+
+	// PutWithTTL Sets the value for a key of the map, the key is hidden once
+	// the given duration has passed
+	PutWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) (operation.Operation, error)
+
+	// Batch Applies several PUT and DEL operations as a single entry, they
+	// are all visible at once to the other peers
+	Batch(ctx context.Context, ops []operation.Operation) (operation.Operation, error)
+
+	// GetAll Retrieves the concurrent values of a key, only available when
+	// the store is opened in multi-value mode
+	GetAll(ctx context.Context, key string) ([][]byte, error)
+
+	// Resolve Sets a value superseding all the concurrent values of a key
+	Resolve(ctx context.Context, key string, value []byte) (operation.Operation, error)
+
+	// History Returns all the operations which modified a key, sorted in
+	// causal order, their entries contain the writer identity and clock
+	History(ctx context.Context, key string) ([]operation.Operation, error)
+
+	// GetAt Retrieves the value a key had when the given entries were the
+	// heads of the log
+	GetAt(ctx context.Context, key string, heads []cid.Cid) ([]byte, error)
+
+	// DiffKeys Returns the keys modified between two states of the store,
+	// empty from heads stand for an empty store
+	DiffKeys(ctx context.Context, from []cid.Cid, to []cid.Cid) (*KeyValueDiff, error)
+
+	// Watch Returns a channel receiving the changes of the keys starting with
+	// the given prefix, whether written locally or replicated, changes are
+	// queued until received, the channel is closed when the context is done
+	// or the store is closed
+	Watch(ctx context.Context, prefix string) <-chan KeyChange
+
+	// Prefix Returns the keys starting with the given prefix and their values
+	Prefix(prefix string) map[string][]byte
+
+	// Range Returns the keys between start (inclusive) and end (exclusive) and
+	// their values, an empty end means no upper bound
+	Range(start string, end string) map[string][]byte
+
+	// Iterator Returns an iterator over the keys of the map in ascending order
+	Iterator(options *KeyValueIteratorOptions) KeyValueIterator
+}
+
+// KeyValueDiff Lists the keys modified between two states of a KeyValueStore
+type KeyValueDiff struct {
+	// Added The keys absent from the first state, with their new value
+	Added map[string][]byte
+
+	// Changed The keys whose value changed, with their new value
+	Changed map[string][]byte
+
+	// Removed The keys absent from the second state, with their old value
+	Removed map[string][]byte
+}
+
+// KeyChange Describes a modification of a key of a KeyValueStore
+type KeyChange struct {
+	// Key The modified key
+	Key string
+
+	// OldValue The value of the key before the change, nil if it was unset
+	OldValue []byte
+
+	// NewValue The value of the key after the change, nil if it was deleted
+	NewValue []byte
+
+	// Operation The operation which modified the key
+	Operation operation.Operation
+
+	// Entry The log entry containing the operation
+	Entry ipfslog.Entry
+}
+
+// KeyValueStoreOptions Lists the options specific to a KeyValueStore, they
+// can be given using the StoreSpecificOpts field of CreateDBOptions
+type KeyValueStoreOptions struct {
+	// ReaperInterval Enables the periodic deletion of the expired keys written
+	// by the current identity
+	ReaperInterval *time.Duration
+
+	// MultiValue Keeps all the concurrent values of a key instead of only
+	// the last one, they are available using GetAll and a
+	// stores.EventConflict is emitted when they diverge
+	MultiValue *bool
+}
+
+// KeyValueIteratorOptions Defines the parameters that can be given to the
+// Iterator function of a KeyValueStore
+type KeyValueIteratorOptions struct {
+	// Start The first key to return, inclusive
+	Start string
+
+	// End The key to stop at, exclusive, an empty value means no upper bound
+	End string
+
+	// Prefix Restricts the keys to the ones starting with this prefix
+	Prefix string
+
+	// Offset The number of keys to skip
+	Offset int
+
+	// Limit The maximum number of keys to return, 0 means no limit
+	Limit int
+}
+
+// KeyValueIterator A cursor over the sorted keys of a KeyValueStore, the
+// keys written while iterating are visible if they are sorted after the
+// cursor
+type KeyValueIterator interface {
+	// Next Moves the cursor to the next key, returns false when done
+	Next() bool
+
+	// Key Returns the key under the cursor
+	Key() string
+
+	// Value Returns the value under the cursor
+	Value() []byte
+}
+
+// DocumentStoreOptions Lists the options specific to a DocumentStore, they
+// can be given using the StoreSpecificOpts field of CreateDBOptions
+type DocumentStoreOptions struct {
+	// IndexBy The document field used as the key of the document, defaults to "_id"
+	IndexBy *string
+}
+
+// DocumentStore A type of store that indexes JSON documents by one of their fields
+type DocumentStore interface {
+	Store
+
+	// Put Adds or replaces a document, it must contain the field used as key
+	Put(ctx context.Context, document map[string]interface{}) (operation.Operation, error)
+
+	// Delete Removes the document stored with the given key
+	Delete(ctx context.Context, key string) (operation.Operation, error)
+
+	// Get Retrieves the document stored with the given key
+	Get(ctx context.Context, key string) (map[string]interface{}, error)
+
+	// Query Returns the documents matched by the filter, sorted by key
+	Query(ctx context.Context, filter func(document map[string]interface{}) bool) ([]map[string]interface{}, error)
+}
+
+// CounterStore A type of store that provides a counter incremented and
+// decremented by several peers
+type CounterStore interface {
+	Store
+
+	// Inc Increments the counter by the given amount
+	Inc(ctx context.Context, amount uint64) (operation.Operation, error)
+
+	// Dec Decrements the counter by the given amount
+	Dec(ctx context.Context, amount uint64) (operation.Operation, error)
+
+	// Value Returns the current value of the counter
+	Value() int64
 }
 
 // StoreIndex Index contains the state of a datastore,
@@ -183,10 +455,36 @@ type StoreIndex interface {
 	// Get Returns the state of the datastore, ie. most up-to-date data
 	Get(key string) interface{}
 
-	// UpdateIndex Applies operations to the Index and updates the state
+	// UpdateIndex Applies operations to the Index and updates the state,
+	// entries only contains the entries added to the log since the previous
+	// call, in no particular order. Indexes which can't be updated
+	// incrementally can rebuild their state from the whole log instead.
 	UpdateIndex(log ipfslog.Log, entries []ipfslog.Entry) error
 }
 
+// PersistentStoreIndex A StoreIndex which state can be saved in the store
+// cache, so loading a store doesn't require to apply the whole log again
+type PersistentStoreIndex interface {
+	StoreIndex
+
+	// Marshal Serializes the current state of the index
+	Marshal() ([]byte, error)
+
+	// Unmarshal Restores the state of the index from serialized data
+	Unmarshal(data []byte) error
+}
+
+// NotifyingStoreIndex A StoreIndex which notifies its changes, the
+// notifications queued by UpdateIndex are run by the store once it has
+// released its locks, so their receivers can read the store
+type NotifyingStoreIndex interface {
+	StoreIndex
+
+	// PendingNotifications Returns and clears the notifications queued by
+	// the previous updates
+	PendingNotifications() []func()
+}
+
 // NewStoreOptions Lists the options to create a new store
 type NewStoreOptions struct {
 	Index                  IndexConstructor
@@ -198,6 +496,12 @@ type NewStoreOptions struct {
 	Replicate              *bool
 	MaxHistory             *int
 	Directory              string
+	SnapshotCompression    *bool
+	ConflictResolver       ConflictResolver
+	PreWriteHooks          []PreWriteHook
+	PostWriteHooks         []OnWritePrototype
+	ValidateHooks          []ValidateHook
+	StoreSpecificOpts      interface{}
 }
 
 // StoreConstructor Defines the expected constructor for a custom store
@@ -206,5 +510,22 @@ type StoreConstructor func(context.Context, coreapi.CoreAPI, *identityprovider.I
 // IndexConstructor Defines the expected constructor for a custom index
 type IndexConstructor func(publicKey []byte) StoreIndex
 
+// ConflictResolver Chooses the operation to keep among concurrent operations
+// writing the same key, it can return one of them or a new operation merging
+// them. The result must not depend on the order of the operations so all the
+// peers converge to the same state. It is supported by the key-value and
+// document stores, which record the wall-clock time of their operations when
+// one is set
+type ConflictResolver func(key string, ops []operation.Operation) operation.Operation
+
 // OnWritePrototype Defines the callback function prototype which is triggered on a write
 type OnWritePrototype func(ctx context.Context, addr cid.Cid, entry ipfslog.Entry, heads []cid.Cid) error
+
+// PreWriteHook Is called before an operation is appended to the oplog, it
+// returns the operation to write, which can differ from the given one, or
+// an error to reject the write
+type PreWriteHook func(ctx context.Context, op operation.Operation) (operation.Operation, error)
+
+// ValidateHook Checks the operations written locally and the ones of the
+// replicated entries, an operation is rejected when an error is returned
+type ValidateHook func(ctx context.Context, op operation.Operation) error
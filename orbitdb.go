@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"path"
 	"strings"
+	"sync"
 
 	"berty.tech/go-ipfs-log/identityprovider"
 	idp "berty.tech/go-ipfs-log/identityprovider"
@@ -27,8 +28,11 @@ import (
 	"berty.tech/go-orbit-db/pubsub/oneonone"
 	"berty.tech/go-orbit-db/pubsub/peermonitor"
 	"berty.tech/go-orbit-db/stores"
+	"berty.tech/go-orbit-db/stores/counterstore"
+	"berty.tech/go-orbit-db/stores/documentstore"
 	"berty.tech/go-orbit-db/stores/eventlogstore"
 	"berty.tech/go-orbit-db/stores/kvstore"
+	"berty.tech/go-orbit-db/stores/schema"
 	"berty.tech/go-orbit-db/utils"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
@@ -46,12 +50,48 @@ type OrbitDB = iface.OrbitDB
 // Store An alias of the type defined in the iface package
 type Store = iface.Store
 
+// ReadOnlyStore An alias of the type defined in the iface package
+type ReadOnlyStore = iface.ReadOnlyStore
+
 // EventLogStore An alias of the type defined in the iface package
 type EventLogStore = iface.EventLogStore
 
+// EventLogCursor An alias of the type defined in the iface package
+type EventLogCursor = iface.EventLogCursor
+
+// EventLogIteratorOptions An alias of the type defined in the iface package
+type EventLogIteratorOptions = iface.EventLogIteratorOptions
+
+// EventLogIterator An alias of the type defined in the iface package
+type EventLogIterator = iface.EventLogIterator
+
 // KeyValueStore An alias of the type defined in the iface package
 type KeyValueStore = iface.KeyValueStore
 
+// KeyValueDiff An alias of the type defined in the iface package
+type KeyValueDiff = iface.KeyValueDiff
+
+// KeyChange An alias of the type defined in the iface package
+type KeyChange = iface.KeyChange
+
+// KeyValueStoreOptions An alias of the type defined in the iface package
+type KeyValueStoreOptions = iface.KeyValueStoreOptions
+
+// KeyValueIteratorOptions An alias of the type defined in the iface package
+type KeyValueIteratorOptions = iface.KeyValueIteratorOptions
+
+// KeyValueIterator An alias of the type defined in the iface package
+type KeyValueIterator = iface.KeyValueIterator
+
+// DocumentStore An alias of the type defined in the iface package
+type DocumentStore = iface.DocumentStore
+
+// DocumentStoreOptions An alias of the type defined in the iface package
+type DocumentStoreOptions = iface.DocumentStoreOptions
+
+// CounterStore An alias of the type defined in the iface package
+type CounterStore = iface.CounterStore
+
 // StoreIndex An alias of the type defined in the iface package
 type StoreIndex = iface.StoreIndex
 
@@ -61,9 +101,18 @@ type StoreConstructor = iface.StoreConstructor
 // IndexConstructor An alias of the type defined in the iface package
 type IndexConstructor = iface.IndexConstructor
 
+// ConflictResolver An alias of the type defined in the iface package
+type ConflictResolver = iface.ConflictResolver
+
 // OnWritePrototype An alias of the type defined in the iface package
 type OnWritePrototype = iface.OnWritePrototype
 
+// PreWriteHook An alias of the type defined in the iface package
+type PreWriteHook = iface.PreWriteHook
+
+// ValidateHook An alias of the type defined in the iface package
+type ValidateHook = iface.ValidateHook
+
 // StreamOptions An alias of the type defined in the iface package
 type StreamOptions = iface.StreamOptions
 
@@ -98,16 +147,24 @@ type NewOrbitDBOptions struct {
 }
 
 type orbitDB struct {
-	ipfs              coreapi.CoreAPI
-	identity          *idp.Identity
-	id                p2pcore.PeerID
-	pubsub            pubsub.Interface
-	keystore          *keystore.Keystore
-	closeKeystore     func() error
-	stores            map[string]Store
+	ipfs          coreapi.CoreAPI
+	identity      *idp.Identity
+	id            p2pcore.PeerID
+	pubsub        pubsub.Interface
+	keystore      *keystore.Keystore
+	closeKeystore func() error
+	directory     string
+	cache         cache.Interface
+
+	// lock Protects the stores, which are modified by the pubsub and store
+	// listeners
+	lock   sync.RWMutex
+	stores map[string]Store
+
+	// connectionsLock Protects the direct connections, it is never held
+	// while a connection is being established
+	connectionsLock   sync.Mutex
 	directConnections map[p2pcore.PeerID]oneonone.Channel
-	directory         string
-	cache             cache.Interface
 }
 
 func (o *orbitDB) Identity() *identityprovider.Identity {
@@ -224,20 +281,30 @@ func NewOrbitDB(ctx context.Context, ipfs coreapi.CoreAPI, options *NewOrbitDBOp
 }
 
 func (o *orbitDB) Close() error {
-	for k, store := range o.stores {
+	// the stores are closed without holding the lock as closing a store
+	// removes it from the map
+	o.lock.Lock()
+	openStores := o.stores
+	o.stores = map[string]Store{}
+	o.lock.Unlock()
+
+	o.connectionsLock.Lock()
+	directConnections := o.directConnections
+	o.directConnections = map[p2pcore.PeerID]oneonone.Channel{}
+	o.connectionsLock.Unlock()
+
+	for _, store := range openStores {
 		err := store.Close()
 		if err != nil {
 			logger().Error("unable to close store", zap.Error(err))
 		}
-		delete(o.stores, k)
 	}
 
-	for k, conn := range o.directConnections {
+	for _, conn := range directConnections {
 		err := conn.Close()
 		if err != nil {
 			logger().Error("unable to close connection", zap.Error(err))
 		}
-		delete(o.directConnections, k)
 	}
 
 	if o.pubsub != nil {
@@ -247,8 +314,6 @@ func (o *orbitDB) Close() error {
 		}
 	}
 
-	o.stores = map[string]Store{}
-
 	err := o.cache.Close()
 	if err != nil {
 		logger().Error("unable to close cache", zap.Error(err))
@@ -279,7 +344,7 @@ func (o *orbitDB) Create(ctx context.Context, name string, storeType string, opt
 	logger().Debug(fmt.Sprintf("Creating database '%s' as %s in '%s'", name, storeType, o.directory))
 
 	// Create the database address
-	dbAddress, err := o.DetermineAddress(ctx, name, storeType, &DetermineAddressOptions{AccessController: options.AccessController})
+	dbAddress, err := o.DetermineAddress(ctx, name, storeType, &DetermineAddressOptions{AccessController: options.AccessController, Schema: options.Schema})
 	if err != nil {
 		return nil, err
 	}
@@ -349,6 +414,48 @@ func (o *orbitDB) KeyValue(ctx context.Context, address string, options *CreateD
 	return kvStore, nil
 }
 
+func (o *orbitDB) Docs(ctx context.Context, address string, options *CreateDBOptions) (DocumentStore, error) {
+	if options == nil {
+		options = &CreateDBOptions{}
+	}
+
+	options.Create = boolPtr(true)
+	options.StoreType = stringPtr("docstore")
+
+	store, err := o.Open(ctx, address, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open database")
+	}
+
+	docStore, ok := store.(DocumentStore)
+	if !ok {
+		return nil, errors.New("unable to cast store to document")
+	}
+
+	return docStore, nil
+}
+
+func (o *orbitDB) Counter(ctx context.Context, address string, options *CreateDBOptions) (CounterStore, error) {
+	if options == nil {
+		options = &CreateDBOptions{}
+	}
+
+	options.Create = boolPtr(true)
+	options.StoreType = stringPtr("counter")
+
+	store, err := o.Open(ctx, address, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to open database")
+	}
+
+	counterStore, ok := store.(CounterStore)
+	if !ok {
+		return nil, errors.New("unable to cast store to counter")
+	}
+
+	return counterStore, nil
+}
+
 func (o *orbitDB) Open(ctx context.Context, dbAddress string, options *CreateDBOptions) (Store, error) {
 	logger().Debug("Open()")
 
@@ -417,7 +524,21 @@ func (o *orbitDB) Open(ctx context.Context, dbAddress string, options *CreateDBO
 
 	options.AccessControllerAddress = manifest.AccessController
 
-	store, err := o.createStore(ctx, manifest.Type, parsedDBAddress, options)
+	validateHooks := options.ValidateHooks
+	if manifest.Schema != "" {
+		if !schema.SupportsStoreType(manifest.Type) {
+			return nil, errors.New(fmt.Sprintf("schemas are not supported by %s databases", manifest.Type))
+		}
+
+		hook, err := o.schemaValidateHook(ctx, manifest.Schema)
+		if err != nil {
+			return nil, err
+		}
+
+		validateHooks = append([]ValidateHook{hook}, options.ValidateHooks...)
+	}
+
+	store, err := o.createStore(ctx, manifest.Type, parsedDBAddress, options, validateHooks)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create store")
 	}
@@ -441,6 +562,10 @@ func (o *orbitDB) DetermineAddress(ctx context.Context, name string, storeType s
 		return nil, errors.New("invalid database type")
 	}
 
+	if len(options.Schema) > 0 && !schema.SupportsStoreType(storeType) {
+		return nil, errors.New(fmt.Sprintf("schemas are not supported by %s databases", storeType))
+	}
+
 	if err := address.IsValid(name); err == nil {
 		return nil, errors.New("given database name is an address, give only the name of the database")
 	}
@@ -462,8 +587,19 @@ func (o *orbitDB) DetermineAddress(ctx context.Context, name string, storeType s
 		return nil, errors.Wrap(err, "unable to create access controller")
 	}
 
+	schemaCID := cid.Undef
+	if len(options.Schema) > 0 {
+		if _, err := schema.Parse(options.Schema); err != nil {
+			return nil, errors.Wrap(err, "invalid schema")
+		}
+
+		if schemaCID, err = utils.WriteSchema(ctx, o.ipfs, options.Schema); err != nil {
+			return nil, errors.Wrap(err, "unable to save schema on ipfs")
+		}
+	}
+
 	// Save the manifest to IPFS
-	manifestHash, err := utils.CreateDBManifest(ctx, o.ipfs, name, storeType, accessControllerAddress.String())
+	manifestHash, err := utils.CreateDBManifestWithSchema(ctx, o.ipfs, name, storeType, accessControllerAddress.String(), schemaCID)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to save manifest on ipfs")
 	}
@@ -520,7 +656,23 @@ func (o *orbitDB) IPFS() coreapi.CoreAPI {
 	return o.ipfs
 }
 
-func (o *orbitDB) createStore(ctx context.Context, storeType string, parsedDBAddress address.Address, options *CreateDBOptions) (Store, error) {
+// schemaValidateHook Returns a validate hook enforcing the schema referenced
+// by a manifest
+func (o *orbitDB) schemaValidateHook(ctx context.Context, schemaPath string) (ValidateHook, error) {
+	data, err := utils.ReadSchema(ctx, o.ipfs, schemaPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch database schema")
+	}
+
+	s, err := schema.Parse(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse database schema")
+	}
+
+	return schema.ValidateHook(s), nil
+}
+
+func (o *orbitDB) createStore(ctx context.Context, storeType string, parsedDBAddress address.Address, options *CreateDBOptions, validateHooks []ValidateHook) (Store, error) {
 	var err error
 	storeFunc, ok := stores.GetConstructor(storeType)
 	if !ok {
@@ -574,11 +726,17 @@ func (o *orbitDB) createStore(ctx context.Context, storeType string, parsedDBAdd
 	}
 
 	store, err := storeFunc(ctx, o.ipfs, identity, parsedDBAddress, &iface.NewStoreOptions{
-		AccessController: accessController,
-		Cache:            options.Cache,
-		Replicate:        options.Replicate,
-		Directory:        *options.Directory,
-		CacheDestroy:     func() error { return o.cache.Destroy(o.directory, parsedDBAddress) },
+		AccessController:    accessController,
+		Cache:               options.Cache,
+		Replicate:           options.Replicate,
+		Directory:           *options.Directory,
+		CacheDestroy:        func() error { return o.cache.Destroy(o.directory, parsedDBAddress) },
+		SnapshotCompression: options.SnapshotCompression,
+		ConflictResolver:    options.ConflictResolver,
+		PreWriteHooks:       options.PreWriteHooks,
+		PostWriteHooks:      options.PostWriteHooks,
+		ValidateHooks:       validateHooks,
+		StoreSpecificOpts:   options.StoreSpecificOpts,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to instantiate store")
@@ -586,7 +744,7 @@ func (o *orbitDB) createStore(ctx context.Context, storeType string, parsedDBAdd
 
 	o.storeListener(ctx, store)
 
-	o.stores[parsedDBAddress.String()] = store
+	o.setStore(parsedDBAddress.String(), store)
 
 	// Subscribe to pubsub to get updates from peers,
 	// this is what hooks us into the message propagation layer
@@ -611,13 +769,36 @@ func (o *orbitDB) onClose(ctx context.Context, addr cid.Cid) error {
 		}
 	}
 
-	delete(o.stores, addr.String())
+	o.deleteStore(addr.String())
 
 	return nil
 }
 
+func (o *orbitDB) getStore(address string) (Store, bool) {
+	o.lock.RLock()
+	defer o.lock.RUnlock()
+
+	store, ok := o.stores[address]
+
+	return store, ok
+}
+
+func (o *orbitDB) setStore(address string, store Store) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.stores[address] = store
+}
+
+func (o *orbitDB) deleteStore(address string) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	delete(o.stores, address)
+}
+
 func (o *orbitDB) storeListener(ctx context.Context, store Store) {
-	go store.Subscribe(ctx, func(evt events.Event) {
+	store.Subscribe(ctx, func(evt events.Event) {
 		switch evt.(type) {
 		case *stores.EventClosed:
 			logger().Debug("received stores.close event")
@@ -654,7 +835,7 @@ func (o *orbitDB) storeListener(ctx context.Context, store Store) {
 }
 
 func (o *orbitDB) pubSubChanListener(ctx context.Context, sub pubsub.Subscription, addr address.Address) {
-	go sub.Subscribe(ctx, func(e events.Event) {
+	sub.Subscribe(ctx, func(e events.Event) {
 		logger().Debug("Got pub sub message")
 		switch e.(type) {
 		case *pubsub.MessageEvent:
@@ -662,7 +843,7 @@ func (o *orbitDB) pubSubChanListener(ctx context.Context, sub pubsub.Subscriptio
 
 			addr := evt.Topic
 
-			store, ok := o.stores[addr]
+			store, ok := o.getStore(addr)
 			if !ok {
 				logger().Error(fmt.Sprintf("unable to find store for address %s", addr))
 				return
@@ -720,7 +901,7 @@ func (o *orbitDB) onNewPeerJoined(ctx context.Context, p p2pcore.PeerID, addr ad
 		return
 	}
 
-	store, ok := o.stores[addr.String()]
+	store, ok := o.getStore(addr.String())
 	if !ok {
 		logger().Error(fmt.Sprintf("unable to get store for address %s", addr.String()))
 		return
@@ -730,7 +911,7 @@ func (o *orbitDB) onNewPeerJoined(ctx context.Context, p p2pcore.PeerID, addr ad
 }
 
 func (o *orbitDB) exchangeHeads(ctx context.Context, p p2pcore.PeerID, addr address.Address) (oneonone.Channel, error) {
-	store, ok := o.stores[addr.String()]
+	store, ok := o.getStore(addr.String())
 	if !ok {
 		return nil, errors.New(fmt.Sprintf("unable to get store for address %s", addr.String()))
 	}
@@ -779,7 +960,7 @@ func (o *orbitDB) exchangeHeads(ctx context.Context, p p2pcore.PeerID, addr addr
 }
 
 func (o *orbitDB) watchOneOnOneMessage(ctx context.Context, channel oneonone.Channel) {
-	go channel.Subscribe(ctx, func(evt events.Event) {
+	channel.Subscribe(ctx, func(evt events.Event) {
 		logger().Debug("received one on one message")
 
 		switch evt.(type) {
@@ -793,7 +974,7 @@ func (o *orbitDB) watchOneOnOneMessage(ctx context.Context, channel oneonone.Cha
 			}
 
 			logger().Debug(fmt.Sprintf("%s: Received %d heads for '%s':", o.id.String(), len(heads.Heads), heads.Address))
-			store, ok := o.stores[heads.Address]
+			store, ok := o.getStore(heads.Address)
 			if !ok {
 				logger().Debug("Heads from unknown store, skipping")
 				return
@@ -816,8 +997,15 @@ func (o *orbitDB) watchOneOnOneMessage(ctx context.Context, channel oneonone.Cha
 	})
 }
 
+// getDirectConnection Returns the channel opened with a peer, the channel is
+// created without holding any lock as it requires network calls, the one
+// registered first is kept when two are created concurrently
 func (o *orbitDB) getDirectConnection(ctx context.Context, peerID p2pcore.PeerID) (oneonone.Channel, error) {
-	if conn, ok := o.directConnections[peerID]; ok {
+	o.connectionsLock.Lock()
+	conn, ok := o.directConnections[peerID]
+	o.connectionsLock.Unlock()
+
+	if ok {
 		return conn, nil
 	}
 
@@ -826,7 +1014,21 @@ func (o *orbitDB) getDirectConnection(ctx context.Context, peerID p2pcore.PeerID
 		return nil, errors.Wrap(err, "unable to create a direct connection with peer")
 	}
 
-	o.directConnections[peerID] = channel
+	o.connectionsLock.Lock()
+	conn, ok = o.directConnections[peerID]
+	if !ok {
+		o.directConnections[peerID] = channel
+	}
+	o.connectionsLock.Unlock()
+
+	if ok {
+		if err := channel.Close(); err != nil {
+			logger().Error("unable to close connection", zap.Error(err))
+		}
+
+		return conn, nil
+	}
+
 	o.watchOneOnOneMessage(ctx, channel)
 
 	return channel, nil
@@ -837,6 +1039,8 @@ var _ OrbitDB = &orbitDB{}
 func init() {
 	stores.RegisterStore("eventlog", eventlogstore.NewOrbitDBEventLogStore)
 	stores.RegisterStore("keyvalue", kvstore.NewOrbitDBKeyValue)
+	stores.RegisterStore("docstore", documentstore.NewOrbitDBDocumentStore)
+	stores.RegisterStore("counter", counterstore.NewOrbitDBCounterStore)
 
 	_ = acbase.AddAccessController(ipfs.NewIPFSAccessController)
 	_ = acbase.AddAccessController(orbitdb.NewOrbitDBAccessController)
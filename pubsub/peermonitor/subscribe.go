@@ -0,0 +1,25 @@
+package peermonitor
+
+import (
+	"context"
+
+	"berty.tech/go-orbit-db/events"
+)
+
+// SubscribePeerJoin Returns a channel receiving the peers joining the
+// monitored topic, it is closed once the context is done
+func SubscribePeerJoin(ctx context.Context, emitter events.EmitterInterface) <-chan *EventPeerJoin {
+	ch := make(chan *EventPeerJoin)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribePeerLeave Returns a channel receiving the peers leaving the
+// monitored topic, it is closed once the context is done
+func SubscribePeerLeave(ctx context.Context, emitter events.EmitterInterface) <-chan *EventPeerLeave {
+	ch := make(chan *EventPeerLeave)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
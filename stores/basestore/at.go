@@ -0,0 +1,117 @@
+package basestore
+
+import (
+	"context"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-orbit-db/address"
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+)
+
+// readOnlyStore A view of a store built from a set of heads
+type readOnlyStore struct {
+	address address.Address
+	index   iface.StoreIndex
+	oplog   ipfslog.Log
+}
+
+func (r *readOnlyStore) Address() address.Address {
+	return r.address
+}
+
+func (r *readOnlyStore) Index() iface.StoreIndex {
+	return r.index
+}
+
+func (r *readOnlyStore) OpLog() ipfslog.Log {
+	return r.oplog
+}
+
+func (r *readOnlyStore) AddOperation(context.Context, operation.Operation, chan<- ipfslog.Entry) (ipfslog.Entry, error) {
+	return nil, errors.New("unable to add an operation to a read-only store")
+}
+
+// At Returns a read-only view of the store containing only the entries
+// reachable from the given heads, its index is built using the index
+// constructor of the store
+func (b *BaseStore) At(ctx context.Context, heads []cid.Cid) (iface.ReadOnlyStore, error) {
+	log, err := b.logAt(ctx, heads)
+	if err != nil {
+		return nil, err
+	}
+
+	index := b.options.Index(b.identity.PublicKey)
+	if err := index.UpdateIndex(log, log.Values().Slice()); err != nil {
+		return nil, errors.Wrap(err, "unable to update index")
+	}
+
+	return &readOnlyStore{
+		address: b.address,
+		index:   index,
+		oplog:   log,
+	}, nil
+}
+
+// Diff Returns the entries reachable from the to heads but not from the from
+// heads, sorted in the log order, empty from heads select all the entries
+func (b *BaseStore) Diff(ctx context.Context, from []cid.Cid, to []cid.Cid) ([]ipfslog.Entry, error) {
+	toLog, err := b.logAt(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(from) == 0 {
+		return toLog.Values().Slice(), nil
+	}
+
+	fromLog, err := b.logAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	fromEntries := fromLog.GetEntries()
+
+	var entries []ipfslog.Entry
+	for _, e := range toLog.Values().Slice() {
+		if _, ok := fromEntries.Get(e.GetHash().String()); !ok {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+// logAt Builds a log containing the entries reachable from the given heads
+func (b *BaseStore) logAt(ctx context.Context, heads []cid.Cid) (ipfslog.Log, error) {
+	if len(heads) == 0 {
+		return nil, errors.New("at least one head is required")
+	}
+
+	var log ipfslog.Log
+
+	for _, h := range heads {
+		l, err := ipfslog.NewFromEntryHash(ctx, b.ipfs, b.identity, h, &ipfslog.LogOptions{
+			ID:               b.OpLog().GetID(),
+			AccessController: b.access,
+		}, &ipfslog.FetchOptions{
+			Length: intPtr(-1),
+		})
+
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to create log from entry hash")
+		}
+
+		if log == nil {
+			log = l
+		} else if log, err = log.Join(l, -1); err != nil {
+			return nil, errors.Wrap(err, "unable to join log")
+		}
+	}
+
+	return log, nil
+}
+
+var _ iface.ReadOnlyStore = &readOnlyStore{}
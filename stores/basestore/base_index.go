@@ -1,21 +1,32 @@
 package basestore
 
 import (
+	"sync"
+
 	ipfslog "berty.tech/go-ipfs-log"
 	"berty.tech/go-orbit-db/iface"
 )
 
 type baseIndex struct {
+	lock  sync.RWMutex
 	id    []byte
 	index []ipfslog.Entry
 }
 
 func (b *baseIndex) Get(_ string) interface{} {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
 	return b.index
 }
 
 func (b *baseIndex) UpdateIndex(log ipfslog.Log, entries []ipfslog.Entry) error {
-	b.index = log.Values().Slice()
+	values := log.Values().Slice()
+
+	b.lock.Lock()
+	b.index = values
+	b.lock.Unlock()
+
 	return nil
 }
 
@@ -1,17 +1,19 @@
 package basestore
 
 import (
+	"bytes"
 	"context"
-	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	ipfslog "berty.tech/go-ipfs-log"
 	logac "berty.tech/go-ipfs-log/accesscontroller"
 	"berty.tech/go-ipfs-log/entry"
 	"berty.tech/go-ipfs-log/identityprovider"
-	"berty.tech/go-ipfs-log/io"
+	ipfslogio "berty.tech/go-ipfs-log/io"
 	"berty.tech/go-orbit-db/accesscontroller"
 	"berty.tech/go-orbit-db/accesscontroller/simple"
 	"berty.tech/go-orbit-db/address"
@@ -23,16 +25,33 @@ import (
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	files "github.com/ipfs/go-ipfs-files"
+	cbornode "github.com/ipfs/go-ipld-cbor"
 	coreapi "github.com/ipfs/interface-go-ipfs-core"
 	"github.com/ipfs/interface-go-ipfs-core/path"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 )
 
-// BaseStore The base of other stores
+// BaseStore The base of other stores, it is safe for concurrent use
 type BaseStore struct {
 	events.EventEmitter
 
+	// lock Protects the fields replaced after the store initialization, it
+	// is only held while reading or replacing them
+	lock sync.RWMutex
+
+	// oplogLock Serializes the changes made to the oplog and the index, it
+	// is held for reading while their content is read
+	oplogLock sync.RWMutex
+
+	// notifications The notifications of the index queued while the oplog
+	// lock is held, they are run by unlockOplog
+	notifications []func()
+
+	// unsavedEntries The number of entries applied to the index since its
+	// state was last saved
+	unsavedEntries int
+
 	id                string
 	identity          *identityprovider.Identity
 	address           address.Address
@@ -58,6 +77,10 @@ type BaseStore struct {
 	directory      string
 	options        *iface.NewStoreOptions
 	cacheDestroy   func() error
+
+	snapshotCompression bool
+
+	hooks storeHooks
 }
 
 func (b *BaseStore) DBName() string {
@@ -73,9 +96,32 @@ func (b *BaseStore) Identity() *identityprovider.Identity {
 }
 
 func (b *BaseStore) OpLog() ipfslog.Log {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
 	return b.oplog
 }
 
+// HasEntry Returns whether the oplog contains the entry with the given hash,
+// unlike reading OpLog() directly it is safe while the oplog is being changed
+func (b *BaseStore) HasEntry(hash cid.Cid) bool {
+	b.oplogLock.RLock()
+	defer b.oplogLock.RUnlock()
+
+	_, ok := b.oplog.GetEntries().Get(hash.String())
+
+	return ok
+}
+
+// Values Returns a snapshot of the entries of the oplog, sorted in the log
+// order, it is safe while the oplog is being changed
+func (b *BaseStore) Values() []ipfslog.Entry {
+	b.oplogLock.RLock()
+	defer b.oplogLock.RUnlock()
+
+	return b.oplog.Values().Slice()
+}
+
 func (b *BaseStore) AccessController() accesscontroller.Interface {
 	return b.access
 }
@@ -144,9 +190,17 @@ func (b *BaseStore) InitBaseStore(ctx context.Context, ipfs coreapi.CoreAPI, ide
 		b.replicate = *options.Replicate
 	}
 
+	if options.SnapshotCompression != nil {
+		b.snapshotCompression = *options.SnapshotCompression
+	}
+
 	b.options = options
 
-	go b.replicator.Subscribe(ctx, func(e events.Event) {
+	b.hooks.preWrite = append(b.hooks.preWrite, options.PreWriteHooks...)
+	b.hooks.postWrite = append(b.hooks.postWrite, options.PostWriteHooks...)
+	b.hooks.validate = append(b.hooks.validate, options.ValidateHooks...)
+
+	b.replicator.Subscribe(ctx, func(e events.Event) {
 		switch e.(type) {
 		case *replicator.EventLoadAdded:
 			evt := e.(*replicator.EventLoadAdded)
@@ -155,19 +209,22 @@ func (b *BaseStore) InitBaseStore(ctx context.Context, ipfs coreapi.CoreAPI, ide
 
 		case *replicator.EventLoadEnd:
 			evt := e.(*replicator.EventLoadEnd)
-			b.replicationLoadComplete(evt.Logs)
+			b.replicationLoadComplete(ctx, evt.Logs)
 
 		case *replicator.EventLoadProgress:
 			evt := e.(*replicator.EventLoadProgress)
 
+			b.oplogLock.RLock()
 			if b.replicationStatus.GetBuffered() > evt.BufferLength {
 				b.recalculateReplicationProgress(b.replicationStatus.GetProgress() + evt.BufferLength)
 			} else {
-				b.recalculateReplicationProgress(b.oplog.Values().Len() + evt.BufferLength)
+				b.recalculateReplicationProgress(b.oplog.GetEntries().Len() + evt.BufferLength)
 			}
 
 			b.replicationStatus.SetBuffered(evt.BufferLength)
 			b.recalculateReplicationMax(b.replicationStatus.GetProgress())
+			b.oplogLock.RUnlock()
+
 			// logger.debug(`<replicate.progress>`)
 			b.Emit(stores.NewEventReplicateProgress(b.Address(), evt.Hash, evt.Latest, b.replicationStatus))
 		}
@@ -197,13 +254,22 @@ func (b *BaseStore) Close() error {
 	b.replicationStatus.Reset()
 
 	// Reset database statistics
+	b.lock.Lock()
 	b.stats.snapshot.bytesLoaded = -1
 	b.stats.syncRequestsReceived = 0
+	b.lock.Unlock()
 
 	b.Emit(stores.NewEventClosed(b.address))
 
 	b.UnsubscribeAll()
 
+	b.oplogLock.RLock()
+	defer b.oplogLock.RUnlock()
+
+	if err := b.saveIndex(); err != nil {
+		logger().Error("unable to save index state", zap.Error(err))
+	}
+
 	err := b.cache.Close()
 	if err != nil {
 		return errors.Wrap(err, "unable to close cache")
@@ -217,6 +283,9 @@ func (b *BaseStore) Address() address.Address {
 }
 
 func (b *BaseStore) Index() iface.StoreIndex {
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+
 	return b.index
 }
 
@@ -242,8 +311,7 @@ func (b *BaseStore) Drop() error {
 	// TODO: Destroy cache? b.cache.Delete()
 
 	// Reset
-	b.index = b.options.Index(b.identity.PublicKey)
-	b.oplog, err = ipfslog.NewLog(b.ipfs, b.identity, &ipfslog.LogOptions{
+	oplog, err := ipfslog.NewLog(b.ipfs, b.identity, &ipfslog.LogOptions{
 		ID:               b.id,
 		AccessController: b.access,
 	})
@@ -252,6 +320,14 @@ func (b *BaseStore) Drop() error {
 		return errors.Wrap(err, "unable to create log")
 	}
 
+	b.oplogLock.Lock()
+	defer b.unlockOplog()
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.index = b.options.Index(b.identity.PublicKey)
+	b.oplog = oplog
 	b.cache = b.options.Cache
 
 	return nil
@@ -264,13 +340,15 @@ func (b *BaseStore) Load(ctx context.Context, amount int) error {
 
 	var localHeads, remoteHeads []*entry.Entry
 	localHeadsBytes, err := b.cache.Get(datastore.NewKey("_localHeads"))
-	if err != nil {
+	if err != nil && err != datastore.ErrNotFound {
 		return errors.Wrap(err, "unable to get local heads from cache")
 	}
 
-	err = json.Unmarshal(localHeadsBytes, &localHeads)
-	if err != nil {
-		return errors.Wrap(err, "unable to unmarshal cached local heads")
+	if localHeadsBytes != nil {
+		err = json.Unmarshal(localHeadsBytes, &localHeads)
+		if err != nil {
+			return errors.Wrap(err, "unable to unmarshal cached local heads")
+		}
 	}
 
 	remoteHeadsBytes, err := b.cache.Get(datastore.NewKey("_remoteHeads"))
@@ -287,6 +365,15 @@ func (b *BaseStore) Load(ctx context.Context, amount int) error {
 
 	heads := append(localHeads, remoteHeads...)
 
+	// events are emitted once the lock is released, so blocking subscribers
+	// can use the store
+	var ready events.Event
+	defer func() {
+		if ready != nil {
+			b.Emit(ready)
+		}
+	}()
+
 	if len(heads) > 0 {
 		headsForEvent := make([]ipfslog.Entry, len(heads))
 		for i := range heads {
@@ -296,6 +383,16 @@ func (b *BaseStore) Load(ctx context.Context, amount int) error {
 		b.Emit(stores.NewEventLoad(b.address, headsForEvent))
 	}
 
+	b.oplogLock.Lock()
+	defer b.unlockOplog()
+
+	var restoredHeads []cid.Cid
+	if len(heads) > 0 {
+		restoredHeads = b.restoreIndex()
+	}
+
+	var newEntries []ipfslog.Entry
+
 	for _, h := range heads {
 		var l ipfslog.Log
 
@@ -314,32 +411,72 @@ func (b *BaseStore) Load(ctx context.Context, amount int) error {
 			return errors.Wrap(err, "unable to create log from entry hash")
 		}
 
+		newEntries = append(newEntries, b.missingEntries(l)...)
+
 		l, err = b.oplog.Join(l, amount)
 		if err != nil {
 			return errors.Wrap(err, "unable to join log")
 		}
 
-		b.oplog = l
+		b.setOpLog(l)
 	}
 
 	// Update the index
 	if len(heads) > 0 {
-		if err := b.updateIndex(); err != nil {
+		newEntries = b.retainedEntries(newEntries)
+
+		if restoredHeads != nil {
+			uncovered, err := b.entriesNotCoveredBy(restoredHeads, newEntries)
+			if err != nil {
+				// the restored state can't be used, rebuild the index from scratch
+				logger().Debug("unable to use restored index state", zap.Error(err))
+				b.setIndex(b.options.Index(b.identity.PublicKey))
+			} else {
+				newEntries = uncovered
+			}
+		}
+
+		if err := b.updateIndex(newEntries); err != nil {
 			return errors.Wrap(err, "unable to update index")
 		}
 	}
 
-	b.Emit(stores.NewEventReady(b.address, b.oplog.Heads().Slice()))
+	ready = stores.NewEventReady(b.address, b.oplog.Heads().Slice())
 	return nil
 }
 
 func (b *BaseStore) Sync(ctx context.Context, heads []ipfslog.Entry) error {
+	b.lock.Lock()
 	b.stats.syncRequestsReceived++
+	b.lock.Unlock()
 
 	if len(heads) == 0 {
 		return nil
 	}
 
+	savedEntriesCIDs, err := b.verifyHeads(ctx, heads)
+	if err != nil {
+		return err
+	}
+
+	b.replicator.Load(ctx, savedEntriesCIDs)
+
+	return nil
+}
+
+// verifyHeads Returns the CIDs of the heads allowed in the oplog, checking
+// that they match their contents
+func (b *BaseStore) verifyHeads(ctx context.Context, heads []ipfslog.Entry) ([]cid.Cid, error) {
+	var invalid []events.Event
+	defer func() {
+		for _, evt := range invalid {
+			b.Emit(evt)
+		}
+	}()
+
+	b.oplogLock.RLock()
+	defer b.oplogLock.RUnlock()
+
 	var savedEntriesCIDs []cid.Cid
 
 	for _, h := range heads {
@@ -354,7 +491,7 @@ func (b *BaseStore) Sync(ctx context.Context, heads []ipfslog.Entry) error {
 
 		identityProvider := b.identity.Provider
 		if identityProvider == nil {
-			return errors.New("identity-provider is required, cannot verify entry")
+			return nil, errors.New("identity-provider is required, cannot verify entry")
 		}
 
 		canAppend := b.access.CanAppend(h, identityProvider, &CanAppendContext{log: b.oplog})
@@ -363,21 +500,25 @@ func (b *BaseStore) Sync(ctx context.Context, heads []ipfslog.Entry) error {
 			continue
 		}
 
-		hash, err := io.WriteCBOR(ctx, b.ipfs, h.ToCborEntry())
+		if err := b.validateEntry(ctx, h); err != nil {
+			logger().Debug("warning: Given input entry was rejected by a validate hook and was discarded.", zap.Error(err))
+			invalid = append(invalid, stores.NewEventInvalidEntry(b.address, h, err))
+			continue
+		}
+
+		hash, err := ipfslogio.WriteCBOR(ctx, b.ipfs, h.ToCborEntry())
 		if err != nil {
-			return errors.Wrap(err, "unable to write entry on dag")
+			return nil, errors.Wrap(err, "unable to write entry on dag")
 		}
 
 		if hash.String() != h.GetHash().String() {
-			return errors.New("WARNING! Head hash didn't match the contents")
+			return nil, errors.New("WARNING! Head hash didn't match the contents")
 		}
 
 		savedEntriesCIDs = append(savedEntriesCIDs, hash)
 	}
 
-	b.replicator.Load(ctx, savedEntriesCIDs)
-
-	return nil
+	return savedEntriesCIDs, nil
 }
 
 func (b *BaseStore) LoadMoreFrom(ctx context.Context, amount uint, cids []cid.Cid) {
@@ -399,6 +540,9 @@ func (b *BaseStore) SaveSnapshot(ctx context.Context) (cid.Cid, error) {
 
 	unfinished := b.replicator.GetQueue()
 
+	b.oplogLock.RLock()
+	defer b.oplogLock.RUnlock()
+
 	untypedEntries := b.oplog.Heads().Slice()
 	entries := make([]*entry.Entry, len(untypedEntries))
 	for i := range untypedEntries {
@@ -410,40 +554,19 @@ func (b *BaseStore) SaveSnapshot(ctx context.Context) (cid.Cid, error) {
 		entries[i] = castedEntry
 	}
 
-	header, err := json.Marshal(&storeSnapshot{
+	header := &storeSnapshot{
 		ID:    b.oplog.GetID(),
 		Heads: entries,
 		Size:  b.oplog.Values().Len(),
 		Type:  b.storeType,
-	})
-
-	if err != nil {
-		return cid.Cid{}, errors.Wrap(err, "unable to serialize snapshot")
 	}
 
-	headerSize := len(header)
-
-	size := make([]byte, 2)
-	binary.BigEndian.PutUint16(size, uint16(headerSize))
-	rs := append(size, header...)
-
-	for _, e := range b.oplog.Values().Slice() {
-		entryJSON, err := json.Marshal(e)
-
-		if err != nil {
-			return cid.Cid{}, errors.Wrap(err, "unable to serialize entry as JSON")
-		}
-
-		size := make([]byte, 2)
-		binary.BigEndian.PutUint16(size, uint16(len(entryJSON)))
-
-		rs = append(rs, size...)
-		rs = append(rs, entryJSON...)
+	rs := &bytes.Buffer{}
+	if err := writeSnapshot(rs, header, b.oplog.Values().Slice(), b.snapshotCompression); err != nil {
+		return cid.Cid{}, errors.Wrap(err, "unable to serialize snapshot")
 	}
 
-	rs = append(rs, 0)
-
-	rsFileNode := files.NewBytesFile(rs)
+	rsFileNode := files.NewBytesFile(rs.Bytes())
 
 	snapshotPath, err := b.ipfs.Unixfs().Add(ctx, rsFileNode)
 	if err != nil {
@@ -479,21 +602,16 @@ func (b *BaseStore) LoadFromSnapshot(ctx context.Context) error {
 	}
 
 	if err != datastore.ErrNotFound {
-		_ = queueJSON
 		var queue []cid.Cid
 
-		var entries []ipfslog.Entry
-
 		if err := json.Unmarshal(queueJSON, &queue); err != nil {
 			return errors.Wrap(err, "unable to deserialize queued CIDs")
 		}
 
-		for _, h := range queue {
-			entries = append(entries, &entry.Entry{Hash: h})
-		}
-
-		if err := b.Sync(ctx, entries); err != nil {
-			return errors.Wrap(err, "unable to sync queued CIDs")
+		// only the hashes are known, the entries are checked by the
+		// validate hooks once fetched by the replicator
+		if len(queue) > 0 {
+			b.replicator.Load(ctx, queue)
 		}
 	}
 
@@ -508,7 +626,31 @@ func (b *BaseStore) LoadFromSnapshot(ctx context.Context) error {
 
 	logger().Debug("loading snapshot from path", zap.String("snapshot", string(snapshot)))
 
-	resNode, err := b.ipfs.Unixfs().Get(ctx, path.New(string(snapshot)))
+	return b.loadSnapshot(ctx, path.New(string(snapshot)), false)
+}
+
+// LoadFromSnapshotCID Loads store content from a snapshot shared by another
+// peer, its entries are verified before being joined to the oplog
+func (b *BaseStore) LoadFromSnapshotCID(ctx context.Context, snapshot cid.Cid) error {
+	b.Emit(stores.NewEventLoad(b.address, nil))
+
+	logger().Debug("loading snapshot from CID", zap.String("snapshot", snapshot.String()))
+
+	return b.loadSnapshot(ctx, path.IpfsPath(snapshot), true)
+}
+
+// loadSnapshot Reads a snapshot and joins its entries to the oplog, if verify
+// is set, the entries are checked against their hash, the access controller
+// and the validate hooks
+func (b *BaseStore) loadSnapshot(ctx context.Context, snapshotPath path.Path, verify bool) error {
+	var invalid events.Event
+	defer func() {
+		if invalid != nil {
+			b.Emit(invalid)
+		}
+	}()
+
+	resNode, err := b.ipfs.Unixfs().Get(ctx, snapshotPath)
 	if err != nil {
 		return errors.Wrap(err, "unable to get snapshot from ipfs")
 	}
@@ -518,43 +660,40 @@ func (b *BaseStore) LoadFromSnapshot(ctx context.Context) error {
 		return errors.New("unable to cast fetched data as a file")
 	}
 
-	headerLengthRaw := make([]byte, 2)
-	if _, err := res.Read(headerLengthRaw); err != nil {
-		return errors.Wrap(err, "unable to read from stream")
+	decoder, err := newSnapshotDecoder(res)
+	if err != nil {
+		return errors.Wrap(err, "unable to read snapshot")
 	}
 
-	headerLength := binary.BigEndian.Uint16(headerLengthRaw)
-	header := &storeSnapshot{}
-	headerRaw := make([]byte, headerLength)
-	if _, err := res.Read(headerRaw); err != nil {
-		return errors.Wrap(err, "unable to read from stream")
-	}
+	header := decoder.Header()
+
+	b.oplogLock.Lock()
+	defer b.unlockOplog()
 
-	if err := json.Unmarshal(headerRaw, &header); err != nil {
-		return errors.Wrap(err, "unable to decode header from ipfs data")
+	if verify && header.ID != b.oplog.GetID() {
+		return errors.New(fmt.Sprintf("snapshot log ID %s doesn't match the store log ID %s", header.ID, b.oplog.GetID()))
 	}
 
-	var entries []ipfslog.Entry
+	var (
+		entries  []ipfslog.Entry
+		verified []*entry.Entry
+	)
 	maxClock := 0
 
-	for i := 0; i < header.Size; i++ {
-		entryLengthRaw := make([]byte, 2)
-		if _, err := res.Read(entryLengthRaw); err != nil {
-			return errors.Wrap(err, "unable to read from stream")
+	for {
+		e, err := decoder.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return errors.Wrap(err, "unable to read snapshot")
 		}
 
-		entryLength := binary.BigEndian.Uint16(entryLengthRaw)
-		e := &entry.Entry{}
-		entryRaw := make([]byte, entryLength)
-
-		if _, err := res.Read(entryRaw); err != nil {
-			return errors.Wrap(err, "unable to read from stream")
-		}
-
-		logger().Debug(fmt.Sprintf("Entry raw: %s", string(entryRaw)))
+		if verify {
+			if err := b.verifySnapshotEntry(e); err != nil {
+				return errors.Wrap(err, "invalid snapshot entry")
+			}
 
-		if err = json.Unmarshal(entryRaw, e); err != nil {
-			return errors.Wrap(err, "unable to unmarshal entry from ipfs data")
+			verified = append(verified, e)
 		}
 
 		entries = append(entries, e)
@@ -563,6 +702,13 @@ func (b *BaseStore) LoadFromSnapshot(ctx context.Context) error {
 		}
 	}
 
+	// the entries are only persisted once all of them have been checked
+	for _, e := range verified {
+		if _, err := ipfslogio.WriteCBOR(ctx, b.ipfs, e.ToCborEntry()); err != nil {
+			return errors.Wrap(err, "unable to write entry on dag")
+		}
+	}
+
 	b.recalculateReplicationMax(maxClock)
 
 	var headsCids []cid.Cid
@@ -586,58 +732,122 @@ func (b *BaseStore) LoadFromSnapshot(ctx context.Context) error {
 		return errors.Wrap(err, "unable to load log")
 	}
 
+	newEntries := b.missingEntries(log)
+
+	if verify {
+		if e, err := b.validateEntries(ctx, newEntries); err != nil {
+			invalid = stores.NewEventInvalidEntry(b.address, e, err)
+			return errors.Wrap(err, "snapshot rejected by a validate hook")
+		}
+	}
+
 	if _, err = b.oplog.Join(log, -1); err != nil {
 		return errors.Wrap(err, "unable to join log")
 	}
 
-	if err := b.updateIndex(); err != nil {
+	if err := b.updateIndex(newEntries); err != nil {
 		return errors.Wrap(err, "unable to update index")
 	}
 
 	return nil
 }
 
+// verifySnapshotEntry Checks that an entry matches its hash and is allowed
+// by the access controller
+func (b *BaseStore) verifySnapshotEntry(e *entry.Entry) error {
+	identityProvider := b.identity.Provider
+	if identityProvider == nil {
+		return errors.New("identity-provider is required, cannot verify entry")
+	}
+
+	if err := b.access.CanAppend(e, identityProvider, &CanAppendContext{log: b.oplog}); err != nil {
+		return errors.Wrap(err, "entry is not allowed in this log")
+	}
+
+	// the hash is computed in memory as the entry is not trusted yet
+	prefix := e.GetHash().Prefix()
+	if prefix.Codec != cid.DagCBOR {
+		return errors.New("entry hash isn't a dag-cbor CID")
+	}
+
+	data, err := cbornode.DumpObject(e.ToCborEntry())
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize entry")
+	}
+
+	hash, err := prefix.Sum(data)
+	if err != nil {
+		return errors.Wrap(err, "unable to compute entry hash")
+	}
+
+	if !hash.Equals(e.GetHash()) {
+		return errors.New("entry hash doesn't match its contents")
+	}
+
+	return nil
+}
+
 func intPtr(i int) *int {
 	return &i
 }
 
 func (b *BaseStore) AddOperation(ctx context.Context, op operation.Operation, onProgressCallback chan<- ipfslog.Entry) (ipfslog.Entry, error) {
+	op, err := b.prepareOperation(ctx, op)
+	if err != nil {
+		return nil, err
+	}
+
 	data, err := op.Marshal()
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to marshal operation")
 	}
 
+	b.oplogLock.Lock()
+	e, heads, err := b.appendOperation(ctx, data)
+	b.unlockOplog()
+
+	if err != nil {
+		return nil, err
+	}
+
+	b.Emit(stores.NewEventWrite(b.address, e, heads))
+	b.runPostWriteHooks(ctx, e, heads)
+
+	if onProgressCallback != nil {
+		onProgressCallback <- e
+	}
+
+	return e, nil
+}
+
+// appendOperation Appends the serialized operation to the oplog and updates
+// the index, it returns the new entry and the heads of the oplog
+func (b *BaseStore) appendOperation(ctx context.Context, data []byte) (ipfslog.Entry, []ipfslog.Entry, error) {
 	e, err := b.oplog.Append(ctx, data, b.referenceCount)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to append data on log")
+		return nil, nil, errors.Wrap(err, "unable to append data on log")
 	}
 	b.recalculateReplicationStatus(b.replicationStatus.GetProgress()+1, e.GetClock().GetTime())
 
 	marshaledEntry, err := json.Marshal([]ipfslog.Entry{e})
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to marshal entry")
+		return nil, nil, errors.Wrap(err, "unable to marshal entry")
 	}
 
 	err = b.cache.Put(datastore.NewKey("_localHeads"), marshaledEntry)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to add data to cache")
+		return nil, nil, errors.Wrap(err, "unable to add data to cache")
 	}
 
-	if err := b.updateIndex(); err != nil {
-		return nil, errors.Wrap(err, "unable to update index")
+	if err := b.updateIndex([]ipfslog.Entry{e}); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to update index")
 	}
 
-	b.Emit(stores.NewEventWrite(b.address, e, b.oplog.Heads().Slice()))
-
-	if onProgressCallback != nil {
-		onProgressCallback <- e
-	}
-
-	return e, nil
+	return e, b.oplog.Heads().Slice(), nil
 }
 
 func (b *BaseStore) recalculateReplicationProgress(max int) {
-	if valuesLen := b.oplog.Values().Len(); b.replicationStatus.GetProgress() < valuesLen {
+	if valuesLen := b.oplog.GetEntries().Len(); b.replicationStatus.GetProgress() < valuesLen {
 		b.replicationStatus.SetProgress(valuesLen)
 
 	} else if b.replicationStatus.GetProgress() < max {
@@ -648,7 +858,7 @@ func (b *BaseStore) recalculateReplicationProgress(max int) {
 }
 
 func (b *BaseStore) recalculateReplicationMax(max int) {
-	if valuesLen := b.oplog.Values().Len(); b.replicationStatus.GetMax() < valuesLen {
+	if valuesLen := b.oplog.GetEntries().Len(); b.replicationStatus.GetMax() < valuesLen {
 		b.replicationStatus.SetMax(valuesLen)
 
 	} else if b.replicationStatus.GetMax() < max {
@@ -661,19 +871,120 @@ func (b *BaseStore) recalculateReplicationStatus(maxProgress, maxTotal int) {
 	b.recalculateReplicationMax(maxTotal)
 }
 
-func (b *BaseStore) updateIndex() error {
+// setOpLog Replaces the oplog, the oplog lock must be held
+func (b *BaseStore) setOpLog(l ipfslog.Log) {
+	b.lock.Lock()
+	b.oplog = l
+	b.lock.Unlock()
+}
+
+// setIndex Replaces the index, the oplog lock must be held
+func (b *BaseStore) setIndex(index iface.StoreIndex) {
+	b.lock.Lock()
+	b.index = index
+	b.lock.Unlock()
+}
+
+// updateIndex Applies the entries newly added to the oplog to the index, as
+// for the other helpers reading the oplog, the oplog lock must be held
+func (b *BaseStore) updateIndex(entries []ipfslog.Entry) error {
 	b.recalculateReplicationMax(0)
-	if err := b.index.UpdateIndex(b.oplog, []ipfslog.Entry{}); err != nil {
+	err := b.index.UpdateIndex(b.oplog, entries)
+
+	if index, ok := b.index.(iface.NotifyingStoreIndex); ok {
+		b.notifications = append(b.notifications, index.PendingNotifications()...)
+	}
+
+	if err != nil {
 		return errors.Wrap(err, "unable to update index")
 	}
 	b.recalculateReplicationProgress(0)
+	b.indexUpdated(len(entries))
 
 	return nil
 }
 
-func (b *BaseStore) replicationLoadComplete(logs []ipfslog.Log) {
+// unlockOplog Releases the oplog write lock then runs the notifications of
+// the index queued meanwhile
+func (b *BaseStore) unlockOplog() {
+	notifications := b.notifications
+	b.notifications = nil
+
+	b.oplogLock.Unlock()
+
+	for _, notify := range notifications {
+		notify()
+	}
+}
+
+// missingEntries Returns the entries of a log which are not yet in the oplog
+func (b *BaseStore) missingEntries(l ipfslog.Log) []ipfslog.Entry {
+	var entries []ipfslog.Entry
+
+	for _, e := range l.Values().Slice() {
+		if _, ok := b.oplog.GetEntries().Get(e.GetHash().String()); !ok {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// retainedEntries Filters out the entries which have not been kept in the
+// oplog, ie. when joining logs with a limited length
+func (b *BaseStore) retainedEntries(entries []ipfslog.Entry) []ipfslog.Entry {
+	var retained []ipfslog.Entry
+	seen := map[string]struct{}{}
+	values := b.oplog.Values()
+
+	for _, e := range entries {
+		hash := e.GetHash().String()
+		if _, ok := seen[hash]; ok {
+			continue
+		}
+
+		seen[hash] = struct{}{}
+
+		if _, ok := values.Get(hash); ok {
+			retained = append(retained, e)
+		}
+	}
+
+	return retained
+}
+
+func (b *BaseStore) replicationLoadComplete(ctx context.Context, logs []ipfslog.Log) {
 	logger().Debug("replication load complete")
+
+	var (
+		invalid    []events.Event
+		replicated events.Event
+	)
+	defer func() {
+		for _, evt := range invalid {
+			b.Emit(evt)
+		}
+
+		if replicated != nil {
+			b.Emit(replicated)
+		}
+	}()
+
+	b.oplogLock.Lock()
+	defer b.unlockOplog()
+
+	var newEntries []ipfslog.Entry
+
 	for _, log := range logs {
+		missing := b.missingEntries(log)
+		if e, err := b.validateEntries(ctx, missing); err != nil {
+			logger().Warn("replicated log rejected by a validate hook", zap.Error(err))
+			invalid = append(invalid, stores.NewEventInvalidEntry(b.address, e, err))
+			continue
+		}
+
+		newEntries = append(newEntries, missing...)
+
 		_, err := b.oplog.Join(log, -1)
 		if err != nil {
 			logger().Error("unable to join logs", zap.Error(err))
@@ -682,7 +993,7 @@ func (b *BaseStore) replicationLoadComplete(logs []ipfslog.Log) {
 	}
 	b.replicationStatus.DecreaseQueued(len(logs))
 	b.replicationStatus.SetBuffered(b.replicator.GetBufferLen())
-	err := b.updateIndex()
+	err := b.updateIndex(newEntries)
 	if err != nil {
 		logger().Error("unable to update index", zap.Error(err))
 		return
@@ -706,7 +1017,7 @@ func (b *BaseStore) replicationLoadComplete(logs []ipfslog.Log) {
 	logger().Debug(fmt.Sprintf("Saved heads %d", heads.Len()))
 
 	// logger.debug(`<replicated>`)
-	b.Emit(stores.NewEventReplicated(b.address, len(logs)))
+	replicated = stores.NewEventReplicated(b.address, len(logs))
 }
 
 type CanAppendContext struct {
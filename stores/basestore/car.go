@@ -0,0 +1,139 @@
+package basestore
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-orbit-db/events"
+	"berty.tech/go-orbit-db/stores"
+	"berty.tech/go-orbit-db/utils"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/pkg/errors"
+)
+
+// ExportCAR Writes the store manifest, its access controller manifest and
+// all the oplog entries as a CARv1 file, the first root is the store manifest
+// and the following ones are the oplog heads
+func (b *BaseStore) ExportCAR(ctx context.Context, w io.Writer) error {
+	manifestCID := b.address.GetRoot()
+
+	blocks, err := utils.ManifestBlocks(ctx, b.ipfs, manifestCID)
+	if err != nil {
+		return errors.Wrap(err, "unable to list manifest blocks")
+	}
+
+	b.oplogLock.RLock()
+	defer b.oplogLock.RUnlock()
+
+	for _, e := range b.oplog.GetEntries().Slice() {
+		blocks = append(blocks, e.GetHash())
+	}
+
+	roots := []cid.Cid{manifestCID}
+	for _, h := range b.oplog.Heads().Slice() {
+		roots = append(roots, h.GetHash())
+	}
+
+	if err := utils.WriteCAR(ctx, b.ipfs, w, roots, blocks); err != nil {
+		return errors.Wrap(err, "unable to write CAR file")
+	}
+
+	return nil
+}
+
+// ImportCAR Adds the blocks of a CAR file written by ExportCAR to IPFS and
+// merges its heads with the store, the whole import is rejected if one of its
+// entries is refused by a validate hook
+func (b *BaseStore) ImportCAR(ctx context.Context, r io.Reader) error {
+	roots, err := utils.ImportCAR(ctx, b.ipfs, r)
+	if err != nil {
+		return errors.Wrap(err, "unable to import CAR file")
+	}
+
+	return b.ImportHeads(ctx, roots)
+}
+
+// ImportHeads Merges the heads listed by the roots of a CAR file written by
+// ExportCAR, its blocks must already have been added to IPFS
+func (b *BaseStore) ImportHeads(ctx context.Context, roots []cid.Cid) error {
+	if len(roots) == 0 || !roots[0].Equals(b.address.GetRoot()) {
+		return errors.New("CAR file doesn't belong to this store")
+	}
+
+	var ready, invalid events.Event
+	defer func() {
+		if invalid != nil {
+			b.Emit(invalid)
+		}
+
+		if ready != nil {
+			b.Emit(ready)
+		}
+	}()
+
+	b.oplogLock.Lock()
+	defer b.unlockOplog()
+
+	var (
+		logs       []ipfslog.Log
+		newEntries []ipfslog.Entry
+	)
+	maxClock := 0
+
+	for _, h := range roots[1:] {
+		l, err := ipfslog.NewFromEntryHash(ctx, b.ipfs, b.identity, h, &ipfslog.LogOptions{
+			ID:               b.oplog.GetID(),
+			AccessController: b.access,
+		}, &ipfslog.FetchOptions{
+			Length:  intPtr(-1),
+			Exclude: b.oplog.Values().Slice(),
+		})
+
+		if err != nil {
+			return errors.Wrap(err, "unable to create log from entry hash")
+		}
+
+		for _, e := range l.Heads().Slice() {
+			if maxClock < e.GetClock().GetTime() {
+				maxClock = e.GetClock().GetTime()
+			}
+		}
+
+		missing := b.missingEntries(l)
+		if e, err := b.validateEntries(ctx, missing); err != nil {
+			invalid = stores.NewEventInvalidEntry(b.address, e, err)
+			return errors.Wrap(err, "CAR file rejected by a validate hook")
+		}
+
+		logs = append(logs, l)
+		newEntries = append(newEntries, missing...)
+	}
+
+	for _, l := range logs {
+		if _, err := b.oplog.Join(l, -1); err != nil {
+			return errors.Wrap(err, "unable to join log")
+		}
+	}
+
+	b.recalculateReplicationMax(maxClock)
+
+	if err := b.updateIndex(b.retainedEntries(newEntries)); err != nil {
+		return errors.Wrap(err, "unable to update index")
+	}
+
+	headsBytes, err := json.Marshal(b.oplog.Heads().Slice())
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize heads cache")
+	}
+
+	if err := b.cache.Put(datastore.NewKey("_remoteHeads"), headsBytes); err != nil {
+		return errors.Wrap(err, "unable to update heads cache")
+	}
+
+	ready = stores.NewEventReady(b.address, b.oplog.Heads().Slice())
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package basestore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// storeHooks The hooks registered on a store, run in their registration order
+type storeHooks struct {
+	lock      sync.RWMutex
+	preWrite  []iface.PreWriteHook
+	postWrite []iface.OnWritePrototype
+	validate  []iface.ValidateHook
+}
+
+func (b *BaseStore) AddPreWriteHook(hook iface.PreWriteHook) {
+	b.hooks.lock.Lock()
+	defer b.hooks.lock.Unlock()
+
+	b.hooks.preWrite = append(b.hooks.preWrite, hook)
+}
+
+func (b *BaseStore) AddPostWriteHook(hook iface.OnWritePrototype) {
+	b.hooks.lock.Lock()
+	defer b.hooks.lock.Unlock()
+
+	b.hooks.postWrite = append(b.hooks.postWrite, hook)
+}
+
+func (b *BaseStore) AddValidateHook(hook iface.ValidateHook) {
+	b.hooks.lock.Lock()
+	defer b.hooks.lock.Unlock()
+
+	b.hooks.validate = append(b.hooks.validate, hook)
+}
+
+// prepareOperation Runs the pre-write hooks then the validate hooks on an
+// operation about to be written, it returns the operation to write
+func (b *BaseStore) prepareOperation(ctx context.Context, op operation.Operation) (operation.Operation, error) {
+	b.hooks.lock.RLock()
+	preWrite := b.hooks.preWrite
+	b.hooks.lock.RUnlock()
+
+	for _, hook := range preWrite {
+		var err error
+		if op, err = hook(ctx, op); err != nil {
+			return nil, errors.Wrap(err, "operation rejected by pre-write hook")
+		}
+
+		if op == nil {
+			return nil, errors.New("pre-write hook returned no operation")
+		}
+	}
+
+	if b.options.ConflictResolver != nil {
+		// resolvers may rely on the wall-clock time of the operations, it is
+		// only recorded when needed as it makes the payloads larger
+		op = operation.WithTimestamp(op, time.Now())
+	}
+
+	if err := b.validateOperation(ctx, op); err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// validateOperation Runs the validate hooks on an operation
+func (b *BaseStore) validateOperation(ctx context.Context, op operation.Operation) error {
+	b.hooks.lock.RLock()
+	validate := b.hooks.validate
+	b.hooks.lock.RUnlock()
+
+	for _, hook := range validate {
+		if err := hook(ctx, op); err != nil {
+			return errors.Wrap(err, "operation rejected by validate hook")
+		}
+	}
+
+	return nil
+}
+
+// validateEntry Runs the validate hooks on the operation of a replicated
+// entry, entries are not parsed when no hook is registered
+func (b *BaseStore) validateEntry(ctx context.Context, e ipfslog.Entry) error {
+	b.hooks.lock.RLock()
+	count := len(b.hooks.validate)
+	b.hooks.lock.RUnlock()
+
+	if count == 0 {
+		return nil
+	}
+
+	op, err := operation.ParseOperation(e)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse entry operation")
+	}
+
+	return b.validateOperation(ctx, op)
+}
+
+// validateEntries Runs the validate hooks on each of the given entries, it
+// returns the first invalid entry
+func (b *BaseStore) validateEntries(ctx context.Context, entries []ipfslog.Entry) (ipfslog.Entry, error) {
+	for _, e := range entries {
+		if err := b.validateEntry(ctx, e); err != nil {
+			return e, errors.Wrapf(err, "invalid entry %s", e.GetHash().String())
+		}
+	}
+
+	return nil, nil
+}
+
+// runPostWriteHooks Runs the post-write hooks once an entry has been written,
+// the entry being already part of the oplog, errors are only logged
+func (b *BaseStore) runPostWriteHooks(ctx context.Context, e ipfslog.Entry, heads []ipfslog.Entry) {
+	b.hooks.lock.RLock()
+	postWrite := b.hooks.postWrite
+	b.hooks.lock.RUnlock()
+
+	if len(postWrite) == 0 {
+		return
+	}
+
+	headsCIDs := make([]cid.Cid, len(heads))
+	for i, h := range heads {
+		headsCIDs[i] = h.GetHash()
+	}
+
+	for _, hook := range postWrite {
+		if err := hook(ctx, b.address.GetRoot(), e, headsCIDs); err != nil {
+			logger().Error("post-write hook failed", zap.Error(err))
+		}
+	}
+}
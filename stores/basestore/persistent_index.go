@@ -0,0 +1,151 @@
+package basestore
+
+import (
+	"encoding/json"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-orbit-db/iface"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+var indexCacheKey = datastore.NewKey("_index")
+
+// indexSaveInterval The number of entries applied to the index after which
+// its state is saved, so a store which isn't closed properly doesn't replay
+// its whole log on the next load
+const indexSaveInterval = 1000
+
+// indexState The serialized state of a PersistentStoreIndex along with the
+// heads of the log it has been computed from
+type indexState struct {
+	Heads []cid.Cid `json:"heads,omitempty"`
+	Index []byte    `json:"index,omitempty"`
+}
+
+// saveIndex Persists the index state in the cache if the index supports it
+func (b *BaseStore) saveIndex() error {
+	index, ok := b.index.(iface.PersistentStoreIndex)
+	if !ok {
+		return nil
+	}
+
+	data, err := index.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize index")
+	}
+
+	heads := b.oplog.Heads().Slice()
+	state := &indexState{
+		Heads: make([]cid.Cid, len(heads)),
+		Index: data,
+	}
+
+	for i, h := range heads {
+		state.Heads[i] = h.GetHash()
+	}
+
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize index state")
+	}
+
+	if err := b.cache.Put(indexCacheKey, stateBytes); err != nil {
+		return errors.Wrap(err, "unable to add index state to cache")
+	}
+
+	return nil
+}
+
+// indexUpdated Counts the entries applied to the index and saves its state
+// every indexSaveInterval entries, the oplog lock must be held
+func (b *BaseStore) indexUpdated(count int) {
+	b.unsavedEntries += count
+	if b.unsavedEntries < indexSaveInterval {
+		return
+	}
+
+	if err := b.saveIndex(); err != nil {
+		logger().Error("unable to save index state", zap.Error(err))
+		return
+	}
+
+	b.unsavedEntries = 0
+}
+
+// restoreIndex Restores the index state from the cache if the index supports
+// it, returns the heads of the log covered by the restored state
+func (b *BaseStore) restoreIndex() []cid.Cid {
+	index, ok := b.index.(iface.PersistentStoreIndex)
+	if !ok || b.oplog.GetEntries().Len() > 0 {
+		return nil
+	}
+
+	stateBytes, err := b.cache.Get(indexCacheKey)
+	if err == datastore.ErrNotFound {
+		return nil
+	} else if err != nil {
+		logger().Error("unable to get index state from cache", zap.Error(err))
+		return nil
+	}
+
+	state := &indexState{}
+	if err := json.Unmarshal(stateBytes, state); err != nil {
+		logger().Error("unable to unmarshal index state", zap.Error(err))
+		return nil
+	}
+
+	if len(state.Heads) == 0 {
+		return nil
+	}
+
+	if err := index.Unmarshal(state.Index); err != nil {
+		logger().Error("unable to restore index state", zap.Error(err))
+		b.setIndex(b.options.Index(b.identity.PublicKey))
+		return nil
+	}
+
+	return state.Heads
+}
+
+// entriesNotCoveredBy Filters out the entries reachable from the given heads,
+// fails if one of the heads is not in the oplog
+func (b *BaseStore) entriesNotCoveredBy(heads []cid.Cid, entries []ipfslog.Entry) ([]ipfslog.Entry, error) {
+	covered := map[string]struct{}{}
+	logEntries := b.oplog.GetEntries()
+
+	for _, h := range heads {
+		if _, ok := logEntries.Get(h.String()); !ok {
+			return nil, errors.New("index state head is not in the log")
+		}
+	}
+
+	stack := append([]cid.Cid{}, heads...)
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if _, ok := covered[h.String()]; ok {
+			continue
+		}
+
+		e, ok := logEntries.Get(h.String())
+		if !ok {
+			continue
+		}
+
+		covered[h.String()] = struct{}{}
+		stack = append(stack, e.GetNext()...)
+	}
+
+	var result []ipfslog.Entry
+	for _, e := range entries {
+		if _, ok := covered[e.GetHash().String()]; !ok {
+			result = append(result, e)
+		}
+	}
+
+	return result, nil
+}
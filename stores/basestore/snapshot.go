@@ -0,0 +1,269 @@
+package basestore
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-ipfs-log/entry"
+	"github.com/pkg/errors"
+)
+
+// Snapshots are stored using the following format (v2):
+//
+//	magic (4 bytes) | version (1 byte) | flags (1 byte) | body
+//
+// The body is gzip compressed when the snapshotFlagCompressed flag is set, it
+// contains the header and the entries JSON, each of them prefixed with its
+// length as an uvarint, followed by the CRC32 (IEEE) of the previous bytes of
+// the body.
+//
+// Snapshots written in the v1 format, without magic number and using an
+// uint16 to prefix the header and entries, are still supported by the decoder.
+const (
+	snapshotMagic          = "ODBS"
+	snapshotVersion        = byte(2)
+	snapshotFlagCompressed = byte(1 << 0)
+
+	// maxSnapshotFrameSize Prevents huge allocations on corrupted snapshots
+	maxSnapshotFrameSize = 1 << 30
+)
+
+// writeSnapshot Encodes a snapshot using the latest format
+func writeSnapshot(w io.Writer, header *storeSnapshot, entries []ipfslog.Entry, compress bool) error {
+	flags := byte(0)
+	if compress {
+		flags |= snapshotFlagCompressed
+	}
+
+	if _, err := w.Write(append([]byte(snapshotMagic), snapshotVersion, flags)); err != nil {
+		return errors.Wrap(err, "unable to write snapshot preamble")
+	}
+
+	var body io.Writer = w
+	var gz *gzip.Writer
+
+	if compress {
+		gz = gzip.NewWriter(w)
+		body = gz
+	}
+
+	checksum := crc32.NewIEEE()
+	framed := io.MultiWriter(body, checksum)
+
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize snapshot header")
+	}
+
+	if err := writeSnapshotFrame(framed, headerJSON); err != nil {
+		return errors.Wrap(err, "unable to write snapshot header")
+	}
+
+	for _, e := range entries {
+		entryJSON, err := json.Marshal(e)
+		if err != nil {
+			return errors.Wrap(err, "unable to serialize entry as JSON")
+		}
+
+		if err := writeSnapshotFrame(framed, entryJSON); err != nil {
+			return errors.Wrap(err, "unable to write snapshot entry")
+		}
+	}
+
+	sum := make([]byte, 4)
+	binary.BigEndian.PutUint32(sum, checksum.Sum32())
+
+	if _, err := body.Write(sum); err != nil {
+		return errors.Wrap(err, "unable to write snapshot checksum")
+	}
+
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return errors.Wrap(err, "unable to flush compressed snapshot")
+		}
+	}
+
+	return nil
+}
+
+func writeSnapshotFrame(w io.Writer, data []byte) error {
+	size := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(size, uint64(len(data)))
+
+	if _, err := w.Write(size[:n]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(data)
+
+	return err
+}
+
+// snapshotDecoder Reads a snapshot entry by entry
+type snapshotDecoder struct {
+	version  byte
+	body     io.Reader
+	framed   io.Reader
+	checksum hash.Hash32
+	header   *storeSnapshot
+	read     int
+}
+
+// newSnapshotDecoder Creates a decoder and reads the snapshot header
+func newSnapshotDecoder(r io.Reader) (*snapshotDecoder, error) {
+	br := bufio.NewReader(r)
+	d := &snapshotDecoder{}
+
+	preamble, err := br.Peek(len(snapshotMagic))
+	if err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "unable to read from stream")
+	}
+
+	if !bytes.Equal(preamble, []byte(snapshotMagic)) {
+		d.version = 1
+		d.body = br
+		d.framed = br
+	} else {
+		fullPreamble := make([]byte, len(snapshotMagic)+2)
+		if _, err := io.ReadFull(br, fullPreamble); err != nil {
+			return nil, errors.Wrap(err, "unable to read snapshot preamble")
+		}
+
+		d.version = fullPreamble[len(snapshotMagic)]
+		flags := fullPreamble[len(snapshotMagic)+1]
+
+		if d.version != snapshotVersion {
+			return nil, errors.New(fmt.Sprintf("unsupported snapshot version %d", d.version))
+		}
+
+		d.body = br
+		if flags&snapshotFlagCompressed != 0 {
+			gz, err := gzip.NewReader(br)
+			if err != nil {
+				return nil, errors.Wrap(err, "unable to read compressed snapshot")
+			}
+
+			d.body = gz
+		}
+
+		d.checksum = crc32.NewIEEE()
+		d.framed = io.TeeReader(d.body, d.checksum)
+	}
+
+	headerRaw, err := d.readFrame()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read snapshot header")
+	}
+
+	d.header = &storeSnapshot{}
+	if err := json.Unmarshal(headerRaw, d.header); err != nil {
+		return nil, errors.Wrap(err, "unable to decode header from ipfs data")
+	}
+
+	return d, nil
+}
+
+// Header Returns the snapshot header
+func (d *snapshotDecoder) Header() *storeSnapshot {
+	return d.header
+}
+
+// Next Returns the next entry of the snapshot, or io.EOF once all the entries
+// have been read and the checksum verified
+func (d *snapshotDecoder) Next() (*entry.Entry, error) {
+	if d.read >= d.header.Size {
+		if err := d.verifyChecksum(); err != nil {
+			return nil, err
+		}
+
+		return nil, io.EOF
+	}
+
+	entryRaw, err := d.readFrame()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read snapshot entry")
+	}
+
+	e := &entry.Entry{}
+	if err := json.Unmarshal(entryRaw, e); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal entry from ipfs data")
+	}
+
+	d.read++
+
+	return e, nil
+}
+
+func (d *snapshotDecoder) readFrame() ([]byte, error) {
+	var size uint64
+
+	if d.version == 1 {
+		sizeRaw := make([]byte, 2)
+		if _, err := io.ReadFull(d.framed, sizeRaw); err != nil {
+			return nil, errors.Wrap(err, "unable to read from stream")
+		}
+
+		size = uint64(binary.BigEndian.Uint16(sizeRaw))
+	} else {
+		var err error
+		size, err = binary.ReadUvarint(byteReader{d.framed})
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read from stream")
+		}
+	}
+
+	if size > maxSnapshotFrameSize {
+		return nil, errors.New(fmt.Sprintf("snapshot frame too large (%d bytes)", size))
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(d.framed, data); err != nil {
+		return nil, errors.Wrap(err, "unable to read from stream")
+	}
+
+	return data, nil
+}
+
+func (d *snapshotDecoder) verifyChecksum() error {
+	if d.checksum == nil {
+		return nil
+	}
+
+	expected := d.checksum.Sum32()
+
+	sum := make([]byte, 4)
+	if _, err := io.ReadFull(d.body, sum); err != nil {
+		return errors.Wrap(err, "unable to read snapshot checksum")
+	}
+
+	if binary.BigEndian.Uint32(sum) != expected {
+		return errors.New("snapshot checksum mismatch")
+	}
+
+	// avoid verifying the checksum again on subsequent calls
+	d.checksum = nil
+
+	return nil
+}
+
+// byteReader Reads bytes one by one, without reading ahead of the frames
+type byteReader struct {
+	io.Reader
+}
+
+func (b byteReader) ReadByte() (byte, error) {
+	buf := make([]byte, 1)
+	if _, err := io.ReadFull(b.Reader, buf); err != nil {
+		return 0, err
+	}
+
+	return buf[0], nil
+}
@@ -0,0 +1,42 @@
+package conflict
+
+import (
+	ipfslog "berty.tech/go-ipfs-log"
+)
+
+// IsAncestor Checks whether the entry with the given clock can be reached
+// from the next entries of e, entries absent from the log are ignored
+func IsAncestor(log ipfslog.Log, ancestor *Clock, e ipfslog.Entry) bool {
+	if ancestor.Time >= e.GetClock().GetTime() {
+		// lamport clocks always increase along the log
+		return false
+	}
+
+	entries := log.GetEntries()
+	seen := map[string]struct{}{}
+	queue := e.GetNext()
+
+	for len(queue) > 0 {
+		current := queue[0].String()
+		queue = queue[1:]
+
+		if current == ancestor.Hash {
+			return true
+		}
+
+		if _, ok := seen[current]; ok {
+			continue
+		}
+
+		seen[current] = struct{}{}
+
+		next, ok := entries.Get(current)
+		if !ok || next.GetClock().GetTime() <= ancestor.Time {
+			continue
+		}
+
+		queue = append(queue, next.GetNext()...)
+	}
+
+	return false
+}
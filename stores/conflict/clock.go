@@ -0,0 +1,37 @@
+package conflict
+
+import (
+	"bytes"
+
+	ipfslog "berty.tech/go-ipfs-log"
+)
+
+// Clock The lamport clock of the entry which last modified a key of an index
+type Clock struct {
+	ID   []byte
+	Time int
+	Hash string
+}
+
+// After Checks whether the clock would be sorted after the given one in the
+// log, which makes its operation the one to keep
+func (c *Clock) After(other *Clock) bool {
+	if c.Time != other.Time {
+		return c.Time > other.Time
+	}
+
+	if cmp := bytes.Compare(c.ID, other.ID); cmp != 0 {
+		return cmp > 0
+	}
+
+	return c.Hash > other.Hash
+}
+
+// NewClock Returns the clock of an entry
+func NewClock(e ipfslog.Entry) *Clock {
+	return &Clock{
+		ID:   e.GetClock().GetID(),
+		Time: e.GetClock().GetTime(),
+		Hash: e.GetHash().String(),
+	}
+}
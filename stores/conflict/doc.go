@@ -0,0 +1,3 @@
+// conflict built-in strategies to resolve concurrent writes to a key, and the
+// per-key clocks used by the indexes to apply them
+package conflict // import "berty.tech/go-orbit-db/stores/conflict"
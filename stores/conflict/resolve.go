@@ -0,0 +1,118 @@
+package conflict
+
+import (
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/pkg/errors"
+)
+
+// Current The state of a key in an index, along with the clock of the entry
+// which set it
+type Current struct {
+	Clock   *Clock
+	Value   []byte
+	Deleted bool
+}
+
+// currentOperation The operation which set the current state of a key, its
+// value is the one of the index as it may result from a merge
+type currentOperation struct {
+	operation.Operation
+
+	op    string
+	value []byte
+}
+
+func (c *currentOperation) GetOperation() string {
+	return c.op
+}
+
+func (c *currentOperation) GetValue() []byte {
+	return c.value
+}
+
+// Resolve Decides whether an operation replaces the current state of a key,
+// it returns the operation and the clock to apply, or nil to keep the
+// current state. The resolver is only called for concurrent operations,
+// otherwise the causally later one wins, as does the operation sorted last
+// in the log when the resolver is nil. Indexes built on basestore use it to
+// honour the ConflictResolver store option
+func Resolve(resolver iface.ConflictResolver, log ipfslog.Log, key string, current *Current, op operation.Operation, clock *Clock) (operation.Operation, *Clock) {
+	lamport := func() (operation.Operation, *Clock) {
+		if clock.After(current.Clock) {
+			return op, clock
+		}
+
+		return nil, nil
+	}
+
+	if resolver == nil || log == nil || op.GetEntry() == nil {
+		return lamport()
+	}
+
+	currentEntry, ok := log.GetEntries().Get(current.Clock.Hash)
+	if !ok {
+		return lamport()
+	}
+
+	if IsAncestor(log, current.Clock, op.GetEntry()) {
+		return op, clock
+	}
+
+	if IsAncestor(log, clock, currentEntry) {
+		return nil, nil
+	}
+
+	currentOp, err := newCurrentOperation(key, current, currentEntry)
+	if err != nil {
+		return lamport()
+	}
+
+	winner := resolver(key, []operation.Operation{currentOp, op})
+
+	switch winner {
+	case nil, currentOp:
+		return nil, nil
+	case op:
+		return op, clock
+	}
+
+	// a merged operation is recorded with the clock sorted last
+	if clock.After(current.Clock) {
+		return winner, clock
+	}
+
+	return winner, current.Clock
+}
+
+// newCurrentOperation Wraps the operation of the given entry which wrote the
+// key, looking it up among the operations of a batch
+func newCurrentOperation(key string, current *Current, e ipfslog.Entry) (operation.Operation, error) {
+	op, err := operation.ParseOperation(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse log operation")
+	}
+
+	if op.GetOperation() == "BATCH" {
+		var found operation.Operation
+
+		for _, sub := range op.GetOperations() {
+			if sub.GetKey() != nil && *sub.GetKey() == key {
+				found = sub
+			}
+		}
+
+		if found == nil {
+			return nil, errors.New("key not found in batch")
+		}
+
+		op = found
+	}
+
+	if current.Deleted {
+		return &currentOperation{Operation: op, op: "DEL"}, nil
+	}
+
+	return &currentOperation{Operation: op, op: "PUT", value: current.Value}, nil
+}
@@ -0,0 +1,119 @@
+package conflict
+
+import (
+	"bytes"
+
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/operation"
+)
+
+// Lamport Keeps the operation sorted last in the log, using the lamport clock
+// of its entry, this is the default behaviour of the stores
+func Lamport(_ string, ops []operation.Operation) operation.Operation {
+	var winner operation.Operation
+
+	for _, op := range ops {
+		if winner == nil || lamportAfter(op, winner) {
+			winner = op
+		}
+	}
+
+	return winner
+}
+
+// Timestamp Keeps the operation with the latest wall-clock timestamp, ties and
+// operations without a timestamp are resolved using Lamport
+func Timestamp(_ string, ops []operation.Operation) operation.Operation {
+	var winner operation.Operation
+
+	for _, op := range ops {
+		if winner == nil || timestampAfter(op, winner) {
+			winner = op
+		}
+	}
+
+	return winner
+}
+
+// WriterPriority Keeps the operation written by the identity appearing first
+// in the given list, unlisted identities have the lowest priority, ties are
+// resolved using Lamport
+func WriterPriority(writers []string) iface.ConflictResolver {
+	priorities := map[string]int{}
+	for i, w := range writers {
+		if _, ok := priorities[w]; !ok {
+			priorities[w] = len(writers) - i
+		}
+	}
+
+	priority := func(op operation.Operation) int {
+		if op.GetEntry() == nil || op.GetEntry().GetIdentity() == nil {
+			return 0
+		}
+
+		return priorities[op.GetEntry().GetIdentity().ID]
+	}
+
+	return func(_ string, ops []operation.Operation) operation.Operation {
+		var winner operation.Operation
+
+		for _, op := range ops {
+			if winner == nil {
+				winner = op
+			} else if p, w := priority(op), priority(winner); p > w || (p == w && lamportAfter(op, winner)) {
+				winner = op
+			}
+		}
+
+		return winner
+	}
+}
+
+// Merge Combines the values of the concurrent operations using the given
+// function, deleted values are given as nil. The function must be
+// commutative and associative since a merged value can be merged again
+func Merge(merge func(key string, values [][]byte) []byte) iface.ConflictResolver {
+	return func(key string, ops []operation.Operation) operation.Operation {
+		values := make([][]byte, len(ops))
+		for i, op := range ops {
+			if op.GetOperation() != "DEL" {
+				values[i] = op.GetValue()
+			}
+		}
+
+		k := key
+
+		return operation.NewOperation(&k, "PUT", merge(key, values))
+	}
+}
+
+// lamportAfter Checks whether the entry of a is sorted after the entry of b
+// in the log, operations without an entry are sorted first
+func lamportAfter(a, b operation.Operation) bool {
+	ea, eb := a.GetEntry(), b.GetEntry()
+	if ea == nil || eb == nil {
+		return eb == nil && ea != nil
+	}
+
+	if ta, tb := ea.GetClock().GetTime(), eb.GetClock().GetTime(); ta != tb {
+		return ta > tb
+	}
+
+	if cmp := bytes.Compare(ea.GetClock().GetID(), eb.GetClock().GetID()); cmp != 0 {
+		return cmp > 0
+	}
+
+	return ea.GetHash().String() > eb.GetHash().String()
+}
+
+func timestampAfter(a, b operation.Operation) bool {
+	ta, tb := a.GetTimestamp(), b.GetTimestamp()
+	if ta == nil || tb == nil || ta.Equal(*tb) {
+		return lamportAfter(a, b)
+	}
+
+	return ta.After(*tb)
+}
+
+var _ iface.ConflictResolver = Lamport
+var _ iface.ConflictResolver = Timestamp
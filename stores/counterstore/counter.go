@@ -0,0 +1,97 @@
+package counterstore
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"berty.tech/go-ipfs-log/identityprovider"
+	"berty.tech/go-orbit-db/address"
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/basestore"
+	"berty.tech/go-orbit-db/stores/operation"
+	coreapi "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/pkg/errors"
+)
+
+type orbitDBCounterStore struct {
+	basestore.BaseStore
+
+	// writeLock Prevents concurrent writes from being computed from the
+	// same contribution, which would lose one of them
+	writeLock sync.Mutex
+}
+
+func (o *orbitDBCounterStore) Inc(ctx context.Context, amount uint64) (operation.Operation, error) {
+	o.writeLock.Lock()
+	defer o.writeLock.Unlock()
+
+	state := o.index().contribution(o.Identity().ID)
+	state.Increments += amount
+
+	return o.write(ctx, state)
+}
+
+func (o *orbitDBCounterStore) Dec(ctx context.Context, amount uint64) (operation.Operation, error) {
+	o.writeLock.Lock()
+	defer o.writeLock.Unlock()
+
+	state := o.index().contribution(o.Identity().ID)
+	state.Decrements += amount
+
+	return o.write(ctx, state)
+}
+
+func (o *orbitDBCounterStore) Value() int64 {
+	return o.index().value()
+}
+
+func (o *orbitDBCounterStore) write(ctx context.Context, state counterState) (operation.Operation, error) {
+	value, err := json.Marshal(&state)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal counter state")
+	}
+
+	id := o.Identity().ID
+	op := operation.NewOperation(&id, "COUNTER", value)
+
+	e, err := o.AddOperation(ctx, op, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while updating counter")
+	}
+
+	op, err = operation.ParseOperation(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse newly created entry")
+	}
+
+	return op, nil
+}
+
+func (o *orbitDBCounterStore) index() *counterIndex {
+	return o.Index().(*counterIndex)
+}
+
+func (o *orbitDBCounterStore) Type() string {
+	return "counter"
+}
+
+// NewOrbitDBCounterStore Instantiates a new CounterStore
+func NewOrbitDBCounterStore(ctx context.Context, ipfs coreapi.CoreAPI, identity *identityprovider.Identity, addr address.Address, options *iface.NewStoreOptions) (i iface.Store, e error) {
+	store := &orbitDBCounterStore{}
+
+	options.Index = NewCounterIndex
+
+	if options.ConflictResolver != nil {
+		return nil, errors.New("counter stores don't support conflict resolvers")
+	}
+
+	err := store.InitBaseStore(ctx, ipfs, identity, addr, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to initialize base store")
+	}
+
+	return store, nil
+}
+
+var _ iface.CounterStore = &orbitDBCounterStore{}
@@ -0,0 +1,2 @@
+// counterstore a PN-Counter store for OrbitDB
+package counterstore // import "berty.tech/go-orbit-db/stores/counterstore"
@@ -0,0 +1,136 @@
+package counterstore
+
+import (
+	"encoding/json"
+	"sync"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/pkg/errors"
+)
+
+// counterState The contribution of a single identity to the counter, each
+// operation carries the whole contribution of its writer so merging them only
+// requires keeping the highest values, regardless of the order they are
+// received in
+type counterState struct {
+	Increments uint64 `json:"inc"`
+	Decrements uint64 `json:"dec"`
+}
+
+type counterIndex struct {
+	lock     sync.RWMutex
+	counters map[string]*counterState
+}
+
+// Get Returns the value of the counter, the key is ignored
+func (i *counterIndex) Get(_ string) interface{} {
+	return i.value()
+}
+
+func (i *counterIndex) value() int64 {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	total := int64(0)
+	for _, c := range i.counters {
+		total += int64(c.Increments) - int64(c.Decrements)
+	}
+
+	return total
+}
+
+func (i *counterIndex) contribution(id string) counterState {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	if c, ok := i.counters[id]; ok {
+		return *c
+	}
+
+	return counterState{}
+}
+
+// UpdateIndex Merges the given entries in the index, merging is idempotent so
+// the entries can be applied in any order
+func (i *counterIndex) UpdateIndex(_ ipfslog.Log, entries []ipfslog.Entry) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, e := range entries {
+		if err := i.applyEntry(e); err != nil {
+			return errors.Wrap(err, "unable to apply counter entry")
+		}
+	}
+
+	return nil
+}
+
+func (i *counterIndex) applyEntry(e ipfslog.Entry) error {
+	item, err := operation.ParseOperation(e)
+	if err != nil {
+		return errors.Wrap(err, "unable to parse log counter operation")
+	}
+
+	if item.GetOperation() != "COUNTER" || item.GetKey() == nil {
+		return nil
+	}
+
+	// An identity can only update its own contribution
+	if e.GetIdentity() == nil || e.GetIdentity().ID != *item.GetKey() {
+		return nil
+	}
+
+	state := counterState{}
+	if err := json.Unmarshal(item.GetValue(), &state); err != nil {
+		return errors.Wrap(err, "unable to unmarshal counter state")
+	}
+
+	current, ok := i.counters[*item.GetKey()]
+	if !ok {
+		current = &counterState{}
+		i.counters[*item.GetKey()] = current
+	}
+
+	if state.Increments > current.Increments {
+		current.Increments = state.Increments
+	}
+
+	if state.Decrements > current.Decrements {
+		current.Decrements = state.Decrements
+	}
+
+	return nil
+}
+
+func (i *counterIndex) Marshal() ([]byte, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return json.Marshal(i.counters)
+}
+
+func (i *counterIndex) Unmarshal(data []byte) error {
+	counters := map[string]*counterState{}
+	if err := json.Unmarshal(data, &counters); err != nil {
+		return errors.Wrap(err, "unable to unmarshal counter index state")
+	}
+
+	i.lock.Lock()
+	i.counters = counters
+	i.lock.Unlock()
+
+	return nil
+}
+
+// NewCounterIndex Creates a new Index instance for a Counter store
+func NewCounterIndex(_ []byte) iface.StoreIndex {
+	return &counterIndex{
+		counters: map[string]*counterState{},
+	}
+}
+
+var _ iface.IndexConstructor = NewCounterIndex
+var _ iface.StoreIndex = &counterIndex{}
+var _ iface.PersistentStoreIndex = &counterIndex{}
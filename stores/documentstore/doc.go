@@ -0,0 +1,2 @@
+// documentstore a document store for OrbitDB
+package documentstore // import "berty.tech/go-orbit-db/stores/documentstore"
@@ -0,0 +1,154 @@
+package documentstore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"berty.tech/go-ipfs-log/identityprovider"
+	"berty.tech/go-orbit-db/address"
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/basestore"
+	"berty.tech/go-orbit-db/stores/operation"
+	coreapi "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/pkg/errors"
+)
+
+// DefaultIndexBy The document field used as a key when none is specified
+const DefaultIndexBy = "_id"
+
+type orbitDBDocumentStore struct {
+	basestore.BaseStore
+	indexBy string
+}
+
+func (o *orbitDBDocumentStore) Put(ctx context.Context, document map[string]interface{}) (operation.Operation, error) {
+	if document == nil {
+		return nil, errors.New("a document is required")
+	}
+
+	key, ok := document[o.indexBy].(string)
+	if !ok || key == "" {
+		return nil, errors.New(fmt.Sprintf("the provided document doesn't contain a string field '%s'", o.indexBy))
+	}
+
+	value, err := json.Marshal(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to serialize document")
+	}
+
+	op := operation.NewOperation(&key, "PUT", value)
+
+	e, err := o.AddOperation(ctx, op, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while adding document")
+	}
+
+	op, err = operation.ParseOperation(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse newly created entry")
+	}
+
+	return op, nil
+}
+
+func (o *orbitDBDocumentStore) Delete(ctx context.Context, key string) (operation.Operation, error) {
+	if o.Index().Get(key) == nil {
+		return nil, errors.New(fmt.Sprintf("no entry with key '%s' in database", key))
+	}
+
+	op := operation.NewOperation(&key, "DEL", nil)
+
+	e, err := o.AddOperation(ctx, op, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while deleting document")
+	}
+
+	op, err = operation.ParseOperation(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse newly created entry")
+	}
+
+	return op, nil
+}
+
+func (o *orbitDBDocumentStore) Get(ctx context.Context, key string) (map[string]interface{}, error) {
+	value, ok := o.Index().Get(key).([]byte)
+	if value == nil {
+		return nil, nil
+	}
+
+	if !ok {
+		return nil, errors.New("unable to cast to bytes")
+	}
+
+	document := map[string]interface{}{}
+	if err := json.Unmarshal(value, &document); err != nil {
+		return nil, errors.Wrap(err, "unable to deserialize document")
+	}
+
+	return document, nil
+}
+
+func (o *orbitDBDocumentStore) Query(ctx context.Context, filter func(document map[string]interface{}) bool) ([]map[string]interface{}, error) {
+	index, ok := o.Index().(*documentIndex)
+	if !ok {
+		return nil, errors.New("unable to cast index to documentIndex")
+	}
+
+	values := index.all()
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var documents []map[string]interface{}
+
+	for _, key := range keys {
+		document := map[string]interface{}{}
+		if err := json.Unmarshal(values[key], &document); err != nil {
+			return nil, errors.Wrap(err, "unable to deserialize document")
+		}
+
+		if filter == nil || filter(document) {
+			documents = append(documents, document)
+		}
+	}
+
+	return documents, nil
+}
+
+func (o *orbitDBDocumentStore) Type() string {
+	return "docstore"
+}
+
+// NewOrbitDBDocumentStore Instantiates a new DocumentStore
+func NewOrbitDBDocumentStore(ctx context.Context, ipfs coreapi.CoreAPI, identity *identityprovider.Identity, addr address.Address, options *iface.NewStoreOptions) (i iface.Store, e error) {
+	store := &orbitDBDocumentStore{
+		indexBy: DefaultIndexBy,
+	}
+
+	if docOptions, ok := options.StoreSpecificOpts.(*iface.DocumentStoreOptions); ok && docOptions.IndexBy != nil {
+		store.indexBy = *docOptions.IndexBy
+	}
+
+	options.Index = func(publicKey []byte) iface.StoreIndex {
+		index := NewDocumentIndex(publicKey).(*documentIndex)
+		index.resolver = options.ConflictResolver
+
+		return index
+	}
+
+	err := store.InitBaseStore(ctx, ipfs, identity, addr, options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to initialize base store")
+	}
+
+	return store, nil
+}
+
+var _ iface.DocumentStore = &orbitDBDocumentStore{}
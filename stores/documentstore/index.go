@@ -0,0 +1,98 @@
+package documentstore
+
+import (
+	"sync"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/conflict"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/pkg/errors"
+)
+
+type documentIndex struct {
+	lock     sync.RWMutex
+	index    map[string][]byte
+	clocks   map[string]*conflict.Clock
+	resolver iface.ConflictResolver
+}
+
+func (i *documentIndex) Get(key string) interface{} {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return i.index[key]
+}
+
+// all Returns a copy of the index content
+func (i *documentIndex) all() map[string][]byte {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	res := make(map[string][]byte, len(i.index))
+	for k, v := range i.index {
+		res[k] = v
+	}
+
+	return res
+}
+
+// UpdateIndex Applies the operations of the new entries, each document keeps
+// the operation of its latest entry, or the one chosen by the conflict
+// resolver among concurrent entries
+func (i *documentIndex) UpdateIndex(log ipfslog.Log, entries []ipfslog.Entry) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, e := range entries {
+		item, err := operation.ParseOperation(e)
+		if err != nil {
+			return errors.Wrap(err, "unable to parse log document operation")
+		}
+
+		i.apply(log, item, conflict.NewClock(e))
+	}
+
+	return nil
+}
+
+// apply Applies a single operation using the clock of its entry, the lock
+// must be held
+func (i *documentIndex) apply(log ipfslog.Log, op operation.Operation, clock *conflict.Clock) {
+	key := op.GetKey()
+	if key == nil {
+		// ignoring entries with nil keys
+		return
+	}
+
+	if current, ok := i.clocks[*key]; ok {
+		value, exists := i.index[*key]
+		state := &conflict.Current{Clock: current, Value: value, Deleted: !exists}
+
+		if op, clock = conflict.Resolve(i.resolver, log, *key, state, op, clock); op == nil {
+			return
+		}
+	}
+
+	switch op.GetOperation() {
+	case "PUT":
+		i.index[*key] = op.GetValue()
+	case "DEL":
+		delete(i.index, *key)
+	default:
+		return
+	}
+
+	i.clocks[*key] = clock
+}
+
+// NewDocumentIndex Creates a new Index instance for a Document store
+func NewDocumentIndex(_ []byte) iface.StoreIndex {
+	return &documentIndex{
+		index:  map[string][]byte{},
+		clocks: map[string]*conflict.Clock{},
+	}
+}
+
+var _ iface.IndexConstructor = NewDocumentIndex
+var _ iface.StoreIndex = &documentIndex{}
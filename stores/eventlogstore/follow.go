@@ -0,0 +1,232 @@
+package eventlogstore
+
+import (
+	"context"
+	"sync"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-orbit-db/stores/operation"
+	"go.uber.org/zap"
+)
+
+// follower Queues the entries added to the index until they are delivered,
+// the queue is unbounded so no entry is dropped when the reader is slow
+type follower struct {
+	lock   sync.Mutex
+	queue  []ipfslog.Entry
+	notify chan struct{}
+
+	// done Is closed when the store is closed
+	done chan struct{}
+}
+
+func newFollower() *follower {
+	return &follower{
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+func (f *follower) push(entries []ipfslog.Entry) {
+	f.lock.Lock()
+	f.queue = append(f.queue, entries...)
+	f.lock.Unlock()
+
+	select {
+	case f.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (f *follower) pop() []ipfslog.Entry {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	entries := f.queue
+	f.queue = nil
+
+	return entries
+}
+
+// followers The followers of an event log store
+type followers struct {
+	lock      sync.Mutex
+	followers map[*follower]struct{}
+}
+
+func (f *followers) add() *follower {
+	fo := newFollower()
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.followers == nil {
+		f.followers = map[*follower]struct{}{}
+	}
+
+	f.followers[fo] = struct{}{}
+
+	return fo
+}
+
+func (f *followers) remove(fo *follower) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.followers, fo)
+}
+
+// removeAll Removes the followers and stops them, their chans are closed
+func (f *followers) removeAll() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for fo := range f.followers {
+		close(fo.done)
+	}
+
+	f.followers = nil
+}
+
+func (f *followers) push(entries []ipfslog.Entry) {
+	if len(entries) == 0 {
+		return
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for fo := range f.followers {
+		fo.push(entries)
+	}
+}
+
+// liveFilter Applies the bounds of the stream options to the entries added
+// after the historical ones were selected
+type liveFilter struct {
+	// after, before The range bounds, entries must be sorted after, or
+	// before, them in the log, or be them when inclusive
+	after           ipfslog.Entry
+	afterInclusive  bool
+	before          ipfslog.Entry
+	beforeInclusive bool
+
+	// since, until The time range, ignored when zero
+	since, until int64
+
+	// remaining The number of entries left to send, -1 for no limit
+	remaining int
+}
+
+// match Checks whether an entry is selected by the bounds
+func (f *liveFilter) match(e ipfslog.Entry, op operation.Operation) bool {
+	hash := e.GetHash().String()
+
+	if f.after != nil {
+		if hash == f.after.GetHash().String() {
+			if !f.afterInclusive {
+				return false
+			}
+		} else if entryBefore(e, f.after) {
+			return false
+		}
+	}
+
+	if f.before != nil {
+		if hash == f.before.GetHash().String() {
+			if !f.beforeInclusive {
+				return false
+			}
+		} else if !entryBefore(e, f.before) {
+			return false
+		}
+	}
+
+	if f.since != 0 || f.until != 0 {
+		t := op.GetTimestamp()
+		if t == nil || (f.since != 0 && t.UnixNano() < f.since) || (f.until != 0 && t.UnixNano() >= f.until) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// follow Sends the given historical entries then the entries queued by the
+// follower which match the filter, the follower being registered before the
+// historical entries are read, entries present in both are only sent once.
+// It stops when the context is done, the store is closed or the amount of
+// entries to send is reached
+func (o *orbitDBEventLogStore) follow(ctx context.Context, fo *follower, history []ipfslog.Entry, filter *liveFilter, out chan<- operation.Operation) {
+	defer close(out)
+	defer o.followers.remove(fo)
+
+	sent := make(map[string]struct{}, len(history))
+
+	send := func(op operation.Operation) bool {
+		select {
+		case out <- op:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-fo.done:
+			return false
+		}
+	}
+
+	for _, e := range history {
+		sent[e.GetHash().String()] = struct{}{}
+
+		op, err := operation.ParseOperation(e)
+		if err != nil {
+			logger().Error("unable to parse operation", zap.Error(err))
+			continue
+		}
+
+		if !send(op) {
+			return
+		}
+	}
+
+	for {
+		for _, e := range fo.pop() {
+			if filter.remaining == 0 {
+				return
+			}
+
+			if _, ok := sent[e.GetHash().String()]; ok {
+				continue
+			}
+
+			op, err := operation.ParseOperation(e)
+			if err != nil {
+				logger().Error("unable to parse operation", zap.Error(err))
+				continue
+			}
+
+			if !filter.match(e, op) {
+				continue
+			}
+
+			if !send(op) {
+				return
+			}
+
+			if filter.remaining > 0 {
+				filter.remaining--
+			}
+		}
+
+		if filter.remaining == 0 {
+			return
+		}
+
+		select {
+		case <-fo.notify:
+		case <-ctx.Done():
+			return
+		case <-fo.done:
+			return
+		}
+	}
+}
@@ -1,24 +1,353 @@
 package eventlogstore
 
 import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"sync"
+
 	ipfslog "berty.tech/go-ipfs-log"
 	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/pkg/errors"
 )
 
+// eventTime The timestamp of an entry of the log
+type eventTime struct {
+	timestamp int64
+	entry     ipfslog.Entry
+}
+
+// eventPositions Returns the position of an entry hash in the entries of the
+// log it was returned with
+type eventPositions func(hash string) (int, bool)
+
+// entryBefore Checks whether the entry a is sorted before the entry b in the
+// log, using their lamport clocks
+func entryBefore(a, b ipfslog.Entry) bool {
+	if ta, tb := a.GetClock().GetTime(), b.GetClock().GetTime(); ta != tb {
+		return ta < tb
+	}
+
+	if cmp := bytes.Compare(a.GetClock().GetID(), b.GetClock().GetID()); cmp != 0 {
+		return cmp < 0
+	}
+
+	return a.GetHash().String() < b.GetHash().String()
+}
+
 type eventIndex struct {
-	index ipfslog.Log
+	// lock Protects the views of the log, they are updated with the entries
+	// given to the index
+	lock sync.Mutex
+
+	// entries The entries of the log in order, the entries of a slice
+	// returned to a reader are never modified so it can be used without
+	// the lock, hashes indexes them by hash
+	entries []ipfslog.Entry
+	hashes  map[string]ipfslog.Entry
+
+	// times The timestamped entries sorted by timestamp
+	times []eventTime
+
+	// restored The state given to Unmarshal, the views are restored from it
+	// on the next update once the log is loaded
+	restored *eventIndexState
+
+	// onUpdate Receives the entries added to the index
+	onUpdate func([]ipfslog.Entry)
 }
 
 func (i *eventIndex) Get(key string) interface{} {
-	if i.index == nil {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.hashes == nil {
 		return nil
 	}
 
-	return i.index.Values().Slice()
+	entries := make([]ipfslog.Entry, len(i.entries))
+	copy(entries, i.entries)
+
+	return entries
+}
+
+// sorted Returns the entries of the log in order and a function finding the
+// position of an entry hash among them, the entries must not be modified
+func (i *eventIndex) sorted() ([]ipfslog.Entry, eventPositions) {
+	i.lock.Lock()
+	entries := i.entries
+	i.lock.Unlock()
+
+	return entries, func(hash string) (int, bool) {
+		return i.position(entries, hash)
+	}
+}
+
+// entry Returns the entry of the log with the given hash
+func (i *eventIndex) entry(hash string) (ipfslog.Entry, bool) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	e, ok := i.hashes[hash]
+
+	return e, ok
+}
+
+// position Finds the entry with the given hash then searches its position
+// in the given entries of the log in O(log n)
+func (i *eventIndex) position(entries []ipfslog.Entry, hash string) (int, bool) {
+	i.lock.Lock()
+	e, ok := i.hashes[hash]
+	i.lock.Unlock()
+
+	if !ok {
+		return 0, false
+	}
+
+	p := sort.Search(len(entries), func(j int) bool {
+		return !entryBefore(entries[j], e)
+	})
+
+	if p == len(entries) || entries[p].GetHash().String() != hash {
+		// added after the entries were returned
+		return 0, false
+	}
+
+	return p, true
 }
 
-func (i *eventIndex) UpdateIndex(log ipfslog.Log, _ []ipfslog.Entry) error {
-	i.index = log
+// between Returns the entries whose timestamp is in the given range, sorted
+// in the log order, a zero bound is ignored. Entries without a timestamp,
+// ie. written by older versions, are never returned
+func (i *eventIndex) between(since, until int64) []ipfslog.Entry {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	start := 0
+	if since != 0 {
+		start = sort.Search(len(i.times), func(j int) bool {
+			return i.times[j].timestamp >= since
+		})
+	}
+
+	end := len(i.times)
+	if until != 0 {
+		end = sort.Search(len(i.times), func(j int) bool {
+			return i.times[j].timestamp >= until
+		})
+	}
+
+	var entries []ipfslog.Entry
+	for j := start; j < end; j++ {
+		entries = append(entries, i.times[j].entry)
+	}
+
+	sort.Slice(entries, func(a, b int) bool {
+		return entryBefore(entries[a], entries[b])
+	})
+
+	return entries
+}
+
+// rebuild Builds the views from all the entries of the log, the lock must be
+// held
+func (i *eventIndex) rebuild(log ipfslog.Log) {
+	i.entries = log.Values().Slice()
+	i.hashes = make(map[string]ipfslog.Entry, len(i.entries))
+	i.times = nil
+
+	sort.SliceStable(i.entries, func(a, b int) bool {
+		return entryBefore(i.entries[a], i.entries[b])
+	})
+
+	for _, e := range i.entries {
+		i.hashes[e.GetHash().String()] = e
+		i.addTime(e)
+	}
+}
+
+// insert Adds the given entries to the views, the lock must be held
+func (i *eventIndex) insert(entries []ipfslog.Entry) {
+	var added []ipfslog.Entry
+
+	for _, e := range entries {
+		hash := e.GetHash().String()
+		if _, ok := i.hashes[hash]; ok {
+			continue
+		}
+
+		i.hashes[hash] = e
+		i.addTime(e)
+
+		added = append(added, e)
+	}
+
+	if len(added) == 0 {
+		return
+	}
+
+	sort.Slice(added, func(a, b int) bool {
+		return entryBefore(added[a], added[b])
+	})
+
+	if len(i.entries) == 0 || entryBefore(i.entries[len(i.entries)-1], added[0]) {
+		// entries written after the known ones, the common case, appending
+		// doesn't modify the entries already returned to the readers
+		i.entries = append(i.entries, added...)
+		return
+	}
+
+	// replicated entries sorted among the known ones are merged into a new
+	// slice as the current one may be in use
+	merged := make([]ipfslog.Entry, 0, len(i.entries)+len(added))
+
+	for len(i.entries) > 0 && len(added) > 0 {
+		if entryBefore(added[0], i.entries[0]) {
+			merged, added = append(merged, added[0]), added[1:]
+		} else {
+			merged, i.entries = append(merged, i.entries[0]), i.entries[1:]
+		}
+	}
+
+	i.entries = append(append(merged, i.entries...), added...)
+}
+
+// addTime Adds an entry to the timestamped entries if it has a timestamp,
+// the lock must be held
+func (i *eventIndex) addTime(e ipfslog.Entry) {
+	op, err := operation.ParseOperation(e)
+	if err != nil || op.GetTimestamp() == nil {
+		return
+	}
+
+	t := eventTime{timestamp: op.GetTimestamp().UnixNano(), entry: e}
+
+	p := sort.Search(len(i.times), func(j int) bool {
+		return i.times[j].timestamp > t.timestamp
+	})
+
+	i.times = append(i.times, eventTime{})
+	copy(i.times[p+1:], i.times[p:])
+	i.times[p] = t
+}
+
+// UpdateIndex Adds the given entries to the views of the log, they are
+// restored from the unmarshaled state or built from the whole log on the
+// first update, and rebuilt when the log no longer contains the same
+// entries, ie. when it has been truncated
+func (i *eventIndex) UpdateIndex(log ipfslog.Log, entries []ipfslog.Entry) error {
+	i.lock.Lock()
+	if i.hashes == nil && !i.restore(log) {
+		i.rebuild(log)
+	} else {
+		i.insert(entries)
+
+		if len(i.entries) != log.GetEntries().Len() {
+			i.rebuild(log)
+		}
+	}
+	i.lock.Unlock()
+
+	if i.onUpdate != nil {
+		i.onUpdate(entries)
+	}
+
+	return nil
+}
+
+// eventIndexState The serialized views of the log, the entries are listed by
+// hash in the log order and the timestamps in their order
+type eventIndexState struct {
+	Hashes []string         `json:"hashes,omitempty"`
+	Times  []eventTimeState `json:"times,omitempty"`
+}
+
+type eventTimeState struct {
+	Timestamp int64  `json:"timestamp"`
+	Hash      string `json:"hash"`
+}
+
+// restore Restores the views from the unmarshaled state, the entries being
+// taken from the log, it returns false when there is no state or when the
+// log doesn't contain its entries, the lock must be held
+func (i *eventIndex) restore(log ipfslog.Log) bool {
+	state := i.restored
+	i.restored = nil
+
+	if state == nil {
+		return false
+	}
+
+	logEntries := log.GetEntries()
+	entries := make([]ipfslog.Entry, len(state.Hashes))
+	hashes := make(map[string]ipfslog.Entry, len(state.Hashes))
+
+	for p, hash := range state.Hashes {
+		e, ok := logEntries.Get(hash)
+		if !ok {
+			return false
+		}
+
+		entries[p] = e
+		hashes[hash] = e
+	}
+
+	times := make([]eventTime, len(state.Times))
+	for p, t := range state.Times {
+		e, ok := hashes[t.Hash]
+		if !ok {
+			return false
+		}
+
+		times[p] = eventTime{timestamp: t.Timestamp, entry: e}
+	}
+
+	i.entries, i.hashes, i.times = entries, hashes, times
+
+	return true
+}
+
+// Marshal Serializes the views of the log, so loading the store doesn't
+// require to parse and sort all the entries again
+func (i *eventIndex) Marshal() ([]byte, error) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	state := &eventIndexState{
+		Hashes: make([]string, len(i.entries)),
+		Times:  make([]eventTimeState, len(i.times)),
+	}
+
+	for p, e := range i.entries {
+		state.Hashes[p] = e.GetHash().String()
+	}
+
+	for p, t := range i.times {
+		state.Times[p] = eventTimeState{Timestamp: t.timestamp, Hash: t.entry.GetHash().String()}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to marshal eventlog index state")
+	}
+
+	return data, nil
+}
+
+// Unmarshal Restores the index state, the views being restored on the next
+// update as the entries are read from the log
+func (i *eventIndex) Unmarshal(data []byte) error {
+	state := &eventIndexState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return errors.Wrap(err, "unable to unmarshal eventlog index state")
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.entries, i.hashes, i.times = nil, nil, nil
+	i.restored = state
 
 	return nil
 }
@@ -30,3 +359,4 @@ func NewEventIndex(_ []byte) iface.StoreIndex {
 
 var _ iface.IndexConstructor = NewEventIndex
 var _ iface.StoreIndex = &eventIndex{}
+var _ iface.PersistentStoreIndex = &eventIndex{}
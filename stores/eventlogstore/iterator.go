@@ -0,0 +1,122 @@
+package eventlogstore
+
+import (
+	"context"
+	"io"
+
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/pkg/errors"
+)
+
+// ErrUnknownCursor Returned when a cursor doesn't match any entry of the log
+var ErrUnknownCursor = errors.New("unknown cursor")
+
+// eventIterator Iterates over the entries of an event log, the position is
+// kept as the hash of the last returned entry so the entries added while
+// iterating are visible
+type eventIterator struct {
+	store   *orbitDBEventLogStore
+	reverse bool
+
+	// cursor The hash of the entry under the iterator, empty when the
+	// iterator is before the first entry
+	cursor string
+
+	// past Set once Next returned io.EOF, the iterator is then after the
+	// cursor entry so Prev returns it again, and entries added later are
+	// still returned by Next
+	past bool
+}
+
+func (it *eventIterator) Next(ctx context.Context) (operation.Operation, error) {
+	return it.move(ctx, 1)
+}
+
+func (it *eventIterator) Prev(ctx context.Context) (operation.Operation, error) {
+	return it.move(ctx, -1)
+}
+
+func (it *eventIterator) Cursor() iface.EventLogCursor {
+	return iface.EventLogCursor(it.cursor)
+}
+
+// move Moves the iterator by the given step, in the iteration order, and
+// returns the entry under it, io.EOF is returned when moving out of the log
+func (it *eventIterator) move(ctx context.Context, step int) (operation.Operation, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	entries, positions := it.store.Index().(*eventIndex).sorted()
+
+	position := -1
+	if it.cursor != "" {
+		p, ok := positions(it.cursor)
+		if !ok {
+			return nil, ErrUnknownCursor
+		}
+
+		position = p
+		if it.reverse {
+			position = len(entries) - 1 - p
+		}
+
+		// the iterator is between the cursor entry and the next one
+		if it.past && step < 0 {
+			position++
+		}
+	}
+
+	position += step
+	if position < 0 {
+		it.cursor = ""
+		it.past = false
+		return nil, io.EOF
+	}
+
+	if position >= len(entries) {
+		it.past = it.cursor != ""
+		return nil, io.EOF
+	}
+
+	if it.reverse {
+		position = len(entries) - 1 - position
+	}
+
+	e := entries[position]
+
+	op, err := operation.ParseOperation(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse operation")
+	}
+
+	it.cursor = e.GetHash().String()
+	it.past = false
+
+	return op, nil
+}
+
+func (o *orbitDBEventLogStore) Iterator(options *iface.EventLogIteratorOptions) (iface.EventLogIterator, error) {
+	if options == nil {
+		options = &iface.EventLogIteratorOptions{}
+	}
+
+	it := &eventIterator{
+		store:   o,
+		reverse: options.Reverse,
+	}
+
+	if options.Cursor != nil {
+		_, positions := o.Index().(*eventIndex).sorted()
+		if _, ok := positions(string(*options.Cursor)); !ok {
+			return nil, ErrUnknownCursor
+		}
+
+		it.cursor = string(*options.Cursor)
+	}
+
+	return it, nil
+}
+
+var _ iface.EventLogIterator = &eventIterator{}
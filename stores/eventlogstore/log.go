@@ -3,6 +3,7 @@ package eventlogstore
 import (
 	ipfslog "berty.tech/go-ipfs-log"
 	"context"
+	"time"
 
 	"berty.tech/go-ipfs-log/identityprovider"
 	"berty.tech/go-orbit-db/address"
@@ -14,34 +15,35 @@ import (
 	"github.com/pkg/errors"
 )
 
+// ErrNotFound Returned when an entry is not part of the log
+var ErrNotFound = errors.New("entry not found")
+
 type orbitDBEventLogStore struct {
 	basestore.BaseStore
+
+	followers followers
 }
 
 func (o *orbitDBEventLogStore) List(ctx context.Context, options *iface.StreamOptions) ([]operation.Operation, error) {
-	var err error
-	var operations []operation.Operation
-	c := make(chan operation.Operation, 10)
-
-	go func() {
-		err = o.Stream(ctx, c, options)
-	}()
-	for op := range c {
-		operations = append(operations, op)
-		if err != nil {
-			return nil, errors.Wrap(err, "unable to list operations")
-		}
+	messages, err := o.query(options)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch query results")
 	}
 
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to list operations")
+	operations := make([]operation.Operation, len(messages))
+	for i, message := range messages {
+		operations[i], err = operation.ParseOperation(message)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse operation")
+		}
 	}
 
 	return operations, nil
 }
 
 func (o *orbitDBEventLogStore) Add(ctx context.Context, value []byte) (operation.Operation, error) {
-	op := operation.NewOperation(nil, "ADD", value)
+	// the timestamp is used by the time range queries
+	op := operation.WithTimestamp(operation.NewOperation(nil, "ADD", value), time.Now())
 
 	e, err := o.AddOperation(ctx, op, nil)
 	if err != nil {
@@ -57,23 +59,26 @@ func (o *orbitDBEventLogStore) Add(ctx context.Context, value []byte) (operation
 }
 
 func (o *orbitDBEventLogStore) Get(ctx context.Context, cid cid.Cid) (operation.Operation, error) {
-	stream := make(chan operation.Operation, 1)
-	one := 1
+	entries, positions := o.Index().(*eventIndex).sorted()
 
-	err := o.Stream(ctx, stream, &iface.StreamOptions{GTE: &cid, Amount: &one})
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to open stream")
+	p, ok := positions(cid.String())
+	if !ok {
+		return nil, ErrNotFound
 	}
 
-	select {
-	case value := <-stream:
-		return value, nil
-	case <-ctx.Done():
-		return nil, errors.New("context deadline exceeded")
+	op, err := operation.ParseOperation(entries[p])
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse operation")
 	}
+
+	return op, nil
 }
 
+// Stream Sends the operations selected by the options to the given chan,
+// which is closed once done, even on error
 func (o *orbitDBEventLogStore) Stream(ctx context.Context, resultChan chan operation.Operation, options *iface.StreamOptions) error {
+	defer close(resultChan)
+
 	messages, err := o.query(options)
 	if err != nil {
 		return errors.Wrap(err, "unable to fetch query results")
@@ -85,27 +90,103 @@ func (o *orbitDBEventLogStore) Stream(ctx context.Context, resultChan chan opera
 			return errors.Wrap(err, "unable to parse operation")
 		}
 
-		resultChan <- op
+		select {
+		case resultChan <- op:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 
-	close(resultChan)
-
 	return nil
 }
 
+func (o *orbitDBEventLogStore) Follow(ctx context.Context, options *iface.StreamOptions) (<-chan operation.Operation, error) {
+	fo := o.followers.add()
+
+	history, err := o.query(options)
+	if err != nil {
+		o.followers.remove(fo)
+		return nil, errors.Wrap(err, "unable to fetch query results")
+	}
+
+	out := make(chan operation.Operation)
+
+	go o.follow(ctx, fo, history, o.liveFilter(options, len(history)), out)
+
+	return out, nil
+}
+
+// liveFilter Returns the filter selecting the entries added after the
+// historical ones, the range bounds have been checked by query. The amount
+// limits the entries following a GT or GTE bound, otherwise it only applies
+// to the historical entries
+func (o *orbitDBEventLogStore) liveFilter(options *iface.StreamOptions, history int) *liveFilter {
+	filter := &liveFilter{remaining: -1}
+	if options == nil {
+		return filter
+	}
+
+	index := o.Index().(*eventIndex)
+
+	bound := func(c *cid.Cid) ipfslog.Entry {
+		e, _ := index.entry(c.String())
+		return e
+	}
+
+	if options.GT != nil || options.GTE != nil {
+		if options.GT != nil {
+			filter.after = bound(options.GT)
+		} else {
+			filter.after, filter.afterInclusive = bound(options.GTE), true
+		}
+
+		filter.remaining = 1
+		if options.Amount != nil && *options.Amount < 0 {
+			filter.remaining = -1
+		} else if options.Amount != nil && *options.Amount > 0 {
+			filter.remaining = *options.Amount
+		}
+
+		if filter.remaining > 0 {
+			if filter.remaining -= history; filter.remaining < 0 {
+				filter.remaining = 0
+			}
+		}
+	} else if options.LT != nil {
+		filter.before = bound(options.LT)
+	} else if options.LTE != nil {
+		filter.before, filter.beforeInclusive = bound(options.LTE), true
+	}
+
+	if options.Since != nil {
+		filter.since = options.Since.UnixNano()
+	}
+
+	if options.Until != nil {
+		filter.until = options.Until.UnixNano()
+	}
+
+	return filter
+}
+
+func (o *orbitDBEventLogStore) Close() error {
+	o.followers.removeAll()
+
+	return o.BaseStore.Close()
+}
+
+// query Returns the entries selected by the options, ErrNotFound is returned
+// when a range bound is not an entry of the log
 func (o *orbitDBEventLogStore) query(options *iface.StreamOptions) ([]ipfslog.Entry, error) {
 	if options == nil {
 		options = &iface.StreamOptions{}
 	}
 
-	uncastedEvents := o.Index().Get("")
-	if uncastedEvents == nil {
-		return nil, nil
-	}
+	index := o.Index().(*eventIndex)
+	events, positions := index.sorted()
 
-	events, ok := o.Index().Get("").([]ipfslog.Entry)
-	if !ok {
-		return nil, errors.New("unable to cast index to entries")
+	if options.Since != nil || options.Until != nil {
+		events, positions = o.timeRange(index, options.Since, options.Until)
 	}
 
 	amount := 1
@@ -119,75 +200,95 @@ func (o *orbitDBEventLogStore) query(options *iface.StreamOptions) ([]ipfslog.En
 		}
 	}
 
-	var c cid.Cid
-	if options.GT != nil || options.GTE != nil {
-		// Greater than case
+	position := func(c *cid.Cid) (int, error) {
+		p, ok := positions(c.String())
+		if !ok {
+			return 0, ErrNotFound
+		}
 
+		return p, nil
+	}
+
+	start, end := 0, len(events)
+
+	if options.GT != nil || options.GTE != nil {
+		// Greater than case, the entries following the bound
 		if options.GT != nil {
-			c = *options.GT
+			p, err := position(options.GT)
+			if err != nil {
+				return nil, err
+			}
+
+			start = p + 1
 		} else {
-			c = *options.GTE
+			p, err := position(options.GTE)
+			if err != nil {
+				return nil, err
+			}
+
+			start = p
 		}
 
-		return o.read(events, c, amount, options.GTE != nil), nil
-	}
+		if start+amount < end {
+			end = start + amount
+		}
+	} else {
+		// Lower than and lastN case, the entries preceding the bound
+		if options.LT != nil {
+			p, err := position(options.LT)
+			if err != nil {
+				return nil, err
+			}
+
+			end = p
+		} else if options.LTE != nil {
+			p, err := position(options.LTE)
+			if err != nil {
+				return nil, err
+			}
+
+			end = p + 1
+		}
 
-	if options.LT != nil {
-		c = *options.LT
-	} else if options.LTE != nil {
-		c = *options.LTE
+		if end-amount > start {
+			start = end - amount
+		}
 	}
 
-	// Reversing events
-	for i := len(events)/2 - 1; i >= 0; i-- {
-		opp := len(events) - 1 - i
-		events[i], events[opp] = events[opp], events[i]
+	if start >= end {
+		return nil, nil
 	}
 
-	// Lower than and lastN case, search latest first by reversing the sequence
-	result := o.read(events, c, amount, options.LTE != nil || options.LT == nil)
-
-	// Reversing result
-	for i := len(result)/2 - 1; i >= 0; i-- {
-		opp := len(result) - 1 - i
-		result[i], result[opp] = result[opp], result[i]
-	}
+	// the sorted entries are shared by the index, they are copied
+	result := make([]ipfslog.Entry, end-start)
+	copy(result, events[start:end])
 
 	return result, nil
 }
 
-func (o *orbitDBEventLogStore) read(ops []ipfslog.Entry, hash cid.Cid, amount int, inclusive bool) []ipfslog.Entry {
-	// Find the index of the gt/lt hash, or start from the beginning of the array if not found
-	startIndex := 0
-	for i, e := range ops {
-		if e.GetHash().String() == hash.String() {
-			startIndex = i
-			break
-		}
+// timeRange Returns the entries whose timestamp is in the given range, in
+// the log order, along with their positions
+func (o *orbitDBEventLogStore) timeRange(index *eventIndex, since, until *time.Time) ([]ipfslog.Entry, eventPositions) {
+	var sinceNano, untilNano int64
+	if since != nil {
+		sinceNano = since.UnixNano()
 	}
 
-	// If gte/lte is set, we include the given hash, if not, start from the next element
-	if !inclusive {
-		startIndex++
+	if until != nil {
+		untilNano = until.UnixNano()
 	}
 
-	var result []ipfslog.Entry
+	events := index.between(sinceNano, untilNano)
 
-	// Slice the array to its requested size
-	for i, e := range ops {
-		if i < startIndex {
-			continue
-		}
-
-		if amount == 0 {
-			break
-		}
-
-		result = append(result, e)
-		amount--
+	positions := make(map[string]int, len(events))
+	for i, e := range events {
+		positions[e.GetHash().String()] = i
 	}
 
-	return result
+	return events, func(hash string) (int, bool) {
+		p, ok := positions[hash]
+		return p, ok
+	}
 }
 
 func (o *orbitDBEventLogStore) Type() string {
@@ -197,7 +298,23 @@ func (o *orbitDBEventLogStore) Type() string {
 // NewOrbitDBEventLogStore Instantiates a new EventLogStore
 func NewOrbitDBEventLogStore(ctx context.Context, ipfs coreapi.CoreAPI, identity *identityprovider.Identity, addr address.Address, options *iface.NewStoreOptions) (i iface.Store, e error) {
 	store := &orbitDBEventLogStore{}
-	options.Index = NewEventIndex
+	options.Index = func(publicKey []byte) iface.StoreIndex {
+		index := NewEventIndex(publicKey).(*eventIndex)
+
+		// only the index of the store feeds the followers, not the ones of
+		// the views returned by At
+		index.onUpdate = func(entries []ipfslog.Entry) {
+			if store.Index() == index {
+				store.followers.push(entries)
+			}
+		}
+
+		return index
+	}
+
+	if options.ConflictResolver != nil {
+		return nil, errors.New("eventlog stores don't support conflict resolvers")
+	}
 
 	err := store.InitBaseStore(ctx, ipfs, identity, addr, options)
 	if err != nil {
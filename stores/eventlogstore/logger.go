@@ -0,0 +1,7 @@
+package eventlogstore
+
+import "go.uber.org/zap"
+
+func logger() *zap.Logger {
+	return zap.L().Named("orbitdb.stores.eventlogstore")
+}
@@ -112,6 +112,41 @@ func NewEventWrite(addr address.Address, e ipfslog.Entry, heads []ipfslog.Entry)
 	}
 }
 
+// EventConflict An event sent when concurrent writes left several values for a key
+type EventConflict struct {
+	Address address.Address
+	Key     string
+	Values  [][]byte
+}
+
+// NewEventConflict Creates a new EventConflict event, deletions are given as
+// nil values
+func NewEventConflict(addr address.Address, key string, values [][]byte) *EventConflict {
+	return &EventConflict{
+		Address: addr,
+		Key:     key,
+		Values:  values,
+	}
+}
+
+// EventInvalidEntry An event sent when an entry received from a peer, a CAR
+// file or a snapshot has been rejected by a validate hook, ie. when its value
+// doesn't match the store schema
+type EventInvalidEntry struct {
+	Address address.Address
+	Entry   ipfslog.Entry
+	Err     error
+}
+
+// NewEventInvalidEntry Creates a new EventInvalidEntry event
+func NewEventInvalidEntry(addr address.Address, e ipfslog.Entry, err error) *EventInvalidEntry {
+	return &EventInvalidEntry{
+		Address: addr,
+		Entry:   e,
+		Err:     err,
+	}
+}
+
 // EventNewPeer An event sent when the store is closed
 type EventClosed struct {
 	Address address.Address
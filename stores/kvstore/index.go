@@ -1,58 +1,300 @@
 package kvstore
 
 import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"time"
+
 	ipfslog "berty.tech/go-ipfs-log"
 	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/conflict"
 	"berty.tech/go-orbit-db/stores/operation"
 	"github.com/pkg/errors"
 )
 
+// kvIndex The index of a KeyValue store, the helpers which aren't exported by
+// the StoreIndex interface expect the lock to be held by their caller unless
+// stated otherwise
 type kvIndex struct {
-	index map[string][]byte
+	lock      sync.RWMutex
+	publicKey []byte
+	index     map[string][]byte
+	keys      *skipList
+	clocks    map[string]*conflict.Clock
+	expiries  map[string]int64
+	now       func() time.Time
+
+	// multiValue Keeps the concurrent values of each key in values
+	multiValue bool
+	values     map[string][]*kvValue
+
+	// resolver Chooses between concurrent operations, the operation sorted
+	// last in the log wins when nil
+	resolver iface.ConflictResolver
+
+	onChange   func(iface.KeyChange)
+	onConflict func(key string, values [][]byte)
+
+	// pending The onChange and onConflict calls queued by the updates, they
+	// are run by the store once it has released the index and oplog locks
+	// so their receivers can read the store
+	pending []func()
 }
 
 func (i *kvIndex) Get(key string) interface{} {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	if i.expired(key) {
+		return nil
+	}
+
 	return i.index[key]
 }
 
-func (i *kvIndex) UpdateIndex(oplog ipfslog.Log, _ []ipfslog.Entry) error {
-	entries := oplog.Values().Slice()
-	size := len(entries)
+// expired Checks whether the value of a key has passed its deadline
+func (i *kvIndex) expired(key string) bool {
+	expiry, ok := i.expiries[key]
+
+	return ok && i.now().UnixNano() >= expiry
+}
+
+// expiredOwnedKeys Returns the expired keys which were last written by the
+// index owner, it acquires the lock
+func (i *kvIndex) expiredOwnedKeys() []string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
 
-	handled := map[string]struct{}{}
+	var keys []string
 
-	for idx := range entries {
-		item, err := operation.ParseOperation(entries[size-idx-1])
+	for key := range i.expiries {
+		if i.expired(key) && bytes.Equal(i.clocks[key].ID, i.publicKey) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// UpdateIndex Applies the given entries to the index, an entry only modifies
+// a key if it is sorted after the last entry which did, so entries can be
+// applied in any order
+func (i *kvIndex) UpdateIndex(log ipfslog.Log, entries []ipfslog.Entry) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	for _, e := range entries {
+		item, err := operation.ParseOperation(e)
 		if err != nil {
 			return errors.Wrap(err, "unable to parse log kv operation")
 		}
 
-		key := item.GetKey()
-		if key == nil {
-			// ignoring entries with nil keys
+		clock := conflict.NewClock(e)
+
+		if item.GetOperation() != "BATCH" {
+			i.apply(log, item, clock)
+			continue
+		}
+
+		ops := item.GetOperations()
+		if !isValidBatch(ops) {
+			// ignoring the whole batch so it is never partially applied
 			continue
 		}
 
-		if _, ok := handled[*item.GetKey()]; !ok {
-			handled[*item.GetKey()] = struct{}{}
+		for _, op := range ops {
+			i.apply(log, op, clock)
+		}
+	}
+
+	return nil
+}
+
+// PendingNotifications Returns the onChange and onConflict calls queued by
+// the updates, the store runs them once its locks are released
+func (i *kvIndex) PendingNotifications() []func() {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	pending := i.pending
+	i.pending = nil
+
+	return pending
+}
+
+// apply Applies a single operation using the clock of its entry, the
+// operations of a batch share the same clock, the last one of a key wins
+func (i *kvIndex) apply(log ipfslog.Log, op operation.Operation, clock *conflict.Clock) {
+	key := op.GetKey()
+	if key == nil {
+		// ignoring entries with nil keys
+		return
+	}
+
+	if i.multiValue && (op.GetOperation() == "PUT" || op.GetOperation() == "DEL") {
+		i.applyMultiValue(log, *key, op, clock)
+	}
+
+	if current, ok := i.clocks[*key]; ok && current != clock {
+		if current.Hash == clock.Hash {
+			// already applied
+			return
+		}
+
+		value, exists := i.index[*key]
+		state := &conflict.Current{Clock: current, Value: value, Deleted: !exists}
+
+		if op, clock = conflict.Resolve(i.resolver, log, *key, state, op, clock); op == nil {
+			return
+		}
+	}
+
+	i.clocks[*key] = clock
+
+	var oldValue []byte
+	if !i.expired(*key) {
+		oldValue = i.index[*key]
+	}
+
+	if op.GetOperation() == "PUT" {
+		i.put(*key, op.GetValue())
+
+		if expiry := op.GetExpiry(); expiry != nil {
+			i.expiries[*key] = expiry.UnixNano()
+		} else {
+			delete(i.expiries, *key)
+		}
+	} else if op.GetOperation() == "DEL" {
+		i.delete(*key)
+	} else {
+		return
+	}
+
+	if onChange := i.onChange; onChange != nil {
+		change := iface.KeyChange{
+			Key:       *key,
+			OldValue:  oldValue,
+			NewValue:  i.index[*key],
+			Operation: op,
+			Entry:     op.GetEntry(),
+		}
+
+		i.pending = append(i.pending, func() { onChange(change) })
+	}
+}
+
+// isValidBatch Checks that all the operations of a batch can be applied
+func isValidBatch(ops []operation.Operation) bool {
+	for _, op := range ops {
+		if op.GetKey() == nil || (op.GetOperation() != "PUT" && op.GetOperation() != "DEL") {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (i *kvIndex) put(key string, value []byte) {
+	if _, ok := i.index[key]; !ok {
+		i.keys.Insert(key)
+	}
+
+	i.index[key] = value
+}
+
+func (i *kvIndex) delete(key string) {
+	if _, ok := i.index[key]; !ok {
+		return
+	}
+
+	i.keys.Delete(key)
+	delete(i.index, key)
+	delete(i.expiries, key)
+}
+
+// all Returns a copy of the index content, it acquires the lock
+func (i *kvIndex) all() map[string][]byte {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
 
-			if item.GetOperation() == "PUT" {
-				i.index[*item.GetKey()] = item.GetValue()
-			} else if item.GetOperation() == "DEL" {
-				delete(i.index, *item.GetKey())
-			}
+	res := make(map[string][]byte, len(i.index))
+	for k, v := range i.index {
+		if !i.expired(k) {
+			res[k] = v
 		}
 	}
 
+	return res
+}
+
+// kvIndexState The serialized state of a kvIndex
+type kvIndexState struct {
+	Index    map[string][]byte          `json:"index,omitempty"`
+	Clocks   map[string]*conflict.Clock `json:"clocks,omitempty"`
+	Expiries map[string]int64           `json:"expiries,omitempty"`
+	Values   map[string][]*kvValue      `json:"values,omitempty"`
+}
+
+func (i *kvIndex) Marshal() ([]byte, error) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	return json.Marshal(&kvIndexState{
+		Index:    i.index,
+		Clocks:   i.clocks,
+		Expiries: i.expiries,
+		Values:   i.values,
+	})
+}
+
+func (i *kvIndex) Unmarshal(data []byte) error {
+	state := &kvIndexState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return errors.Wrap(err, "unable to unmarshal kv index state")
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	i.index = map[string][]byte{}
+	i.keys = newSkipList()
+	for k, v := range state.Index {
+		i.put(k, v)
+	}
+
+	i.clocks = map[string]*conflict.Clock{}
+	for k, v := range state.Clocks {
+		i.clocks[k] = v
+	}
+
+	i.expiries = map[string]int64{}
+	for k, v := range state.Expiries {
+		i.expiries[k] = v
+	}
+
+	i.values = map[string][]*kvValue{}
+	for k, v := range state.Values {
+		i.values[k] = v
+	}
+
 	return nil
 }
 
 // NewKVIndex Creates a new Index instance for a KeyValue store
-func NewKVIndex(_ []byte) iface.StoreIndex {
+func NewKVIndex(publicKey []byte) iface.StoreIndex {
 	return &kvIndex{
-		index: map[string][]byte{},
+		publicKey: publicKey,
+		index:     map[string][]byte{},
+		keys:      newSkipList(),
+		clocks:    map[string]*conflict.Clock{},
+		expiries:  map[string]int64{},
+		values:    map[string][]*kvValue{},
+		now:       time.Now,
 	}
 }
 
 var _ iface.IndexConstructor = NewKVIndex
 var _ iface.StoreIndex = &kvIndex{}
+var _ iface.PersistentStoreIndex = &kvIndex{}
+var _ iface.NotifyingStoreIndex = &kvIndex{}
@@ -0,0 +1,100 @@
+package kvstore
+
+import (
+	"strings"
+
+	"berty.tech/go-orbit-db/iface"
+)
+
+// kvIterator Iterates over the keys of a kvIndex, the position is kept as the
+// last returned key so the index can be modified while iterating
+type kvIterator struct {
+	index   *kvIndex
+	options iface.KeyValueIteratorOptions
+	started bool
+	done    bool
+	count   int
+	key     string
+	value   []byte
+}
+
+func newKVIterator(index *kvIndex, options *iface.KeyValueIteratorOptions) *kvIterator {
+	it := &kvIterator{index: index}
+
+	if options != nil {
+		it.options = *options
+	}
+
+	if it.options.Start < it.options.Prefix {
+		it.options.Start = it.options.Prefix
+	}
+
+	return it
+}
+
+func (it *kvIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	it.index.lock.RLock()
+	defer it.index.lock.RUnlock()
+
+	var n *skipListNode
+	if !it.started {
+		n = it.skipExpired(it.index.keys.Seek(it.options.Start))
+
+		for skipped := 0; n != nil && skipped < it.options.Offset; skipped++ {
+			n = it.skipExpired(n.next[0])
+		}
+
+		it.started = true
+	} else {
+		n = it.index.keys.Seek(it.key)
+		if n != nil && n.key == it.key {
+			n = n.next[0]
+		}
+
+		n = it.skipExpired(n)
+	}
+
+	if n == nil || !it.inBounds(n.key) || (it.options.Limit > 0 && it.count >= it.options.Limit) {
+		it.done = true
+		it.key, it.value = "", nil
+
+		return false
+	}
+
+	it.count++
+	it.key, it.value = n.key, it.index.index[n.key]
+
+	return true
+}
+
+// skipExpired Returns the first node from the given one holding a key which
+// hasn't expired
+func (it *kvIterator) skipExpired(n *skipListNode) *skipListNode {
+	for n != nil && it.index.expired(n.key) {
+		n = n.next[0]
+	}
+
+	return n
+}
+
+func (it *kvIterator) inBounds(key string) bool {
+	if it.options.End != "" && key >= it.options.End {
+		return false
+	}
+
+	return strings.HasPrefix(key, it.options.Prefix)
+}
+
+func (it *kvIterator) Key() string {
+	return it.key
+}
+
+func (it *kvIterator) Value() []byte {
+	return it.value
+}
+
+var _ iface.KeyValueIterator = &kvIterator{}
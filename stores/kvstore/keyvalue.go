@@ -1,7 +1,9 @@
 package kvstore
 
 import (
+	"bytes"
 	"context"
+	"time"
 
 	"berty.tech/go-ipfs-log/identityprovider"
 	"berty.tech/go-orbit-db/address"
@@ -9,16 +11,44 @@ import (
 	"berty.tech/go-orbit-db/stores"
 	"berty.tech/go-orbit-db/stores/basestore"
 	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/ipfs/go-cid"
 	coreapi "github.com/ipfs/interface-go-ipfs-core"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
 type orbitDBKeyValue struct {
 	basestore.BaseStore
+
+	stopReaper context.CancelFunc
+	watchers   kvWatchers
 }
 
 func (o *orbitDBKeyValue) All() map[string][]byte {
-	return o.Index().(*kvIndex).index
+	return o.Index().(*kvIndex).all()
+}
+
+func (o *orbitDBKeyValue) Prefix(prefix string) map[string][]byte {
+	return o.collect(&iface.KeyValueIteratorOptions{Prefix: prefix})
+}
+
+func (o *orbitDBKeyValue) Range(start string, end string) map[string][]byte {
+	return o.collect(&iface.KeyValueIteratorOptions{Start: start, End: end})
+}
+
+func (o *orbitDBKeyValue) Iterator(options *iface.KeyValueIteratorOptions) iface.KeyValueIterator {
+	return newKVIterator(o.Index().(*kvIndex), options)
+}
+
+func (o *orbitDBKeyValue) collect(options *iface.KeyValueIteratorOptions) map[string][]byte {
+	res := map[string][]byte{}
+
+	it := o.Iterator(options)
+	for it.Next() {
+		res[it.Key()] = it.Value()
+	}
+
+	return res
 }
 
 func (o *orbitDBKeyValue) Put(ctx context.Context, key string, value []byte) (operation.Operation, error) {
@@ -37,6 +67,26 @@ func (o *orbitDBKeyValue) Put(ctx context.Context, key string, value []byte) (op
 	return op, nil
 }
 
+func (o *orbitDBKeyValue) PutWithTTL(ctx context.Context, key string, value []byte, ttl time.Duration) (operation.Operation, error) {
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+
+	op := operation.NewOperationWithExpiry(&key, "PUT", value, time.Now().Add(ttl))
+
+	e, err := o.AddOperation(ctx, op, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while putting value")
+	}
+
+	op, err = operation.ParseOperation(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse newly created entry")
+	}
+
+	return op, nil
+}
+
 func (o *orbitDBKeyValue) Delete(ctx context.Context, key string) (operation.Operation, error) {
 	op := operation.NewOperation(&key, "DEL", nil)
 
@@ -53,6 +103,28 @@ func (o *orbitDBKeyValue) Delete(ctx context.Context, key string) (operation.Ope
 	return op, nil
 }
 
+func (o *orbitDBKeyValue) Batch(ctx context.Context, ops []operation.Operation) (operation.Operation, error) {
+	if len(ops) == 0 {
+		return nil, errors.New("a batch requires at least one operation")
+	}
+
+	if !isValidBatch(ops) {
+		return nil, errors.New("a batch can only contain PUT and DEL operations with a key")
+	}
+
+	e, err := o.AddOperation(ctx, operation.NewBatchOperation(ops), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error while writing batch")
+	}
+
+	op, err := operation.ParseOperation(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse newly created entry")
+	}
+
+	return op, nil
+}
+
 func (o *orbitDBKeyValue) Get(ctx context.Context, key string) ([]byte, error) {
 	value, ok := o.Index().(*kvIndex).Get(key).([]byte)
 	if value == nil {
@@ -66,10 +138,148 @@ func (o *orbitDBKeyValue) Get(ctx context.Context, key string) ([]byte, error) {
 	return value, nil
 }
 
+func (o *orbitDBKeyValue) GetAll(ctx context.Context, key string) ([][]byte, error) {
+	index := o.Index().(*kvIndex)
+	if !index.multiValue {
+		return nil, errors.New("multi-value mode is not enabled for this store")
+	}
+
+	return index.getAll(key), nil
+}
+
+func (o *orbitDBKeyValue) Resolve(ctx context.Context, key string, value []byte) (operation.Operation, error) {
+	if !o.Index().(*kvIndex).multiValue {
+		return nil, errors.New("multi-value mode is not enabled for this store")
+	}
+
+	// the new entry references all the heads of the log, so it supersedes
+	// every concurrent value known locally
+	return o.Put(ctx, key, value)
+}
+
+func (o *orbitDBKeyValue) History(ctx context.Context, key string) ([]operation.Operation, error) {
+	var history []operation.Operation
+
+	for _, e := range o.Values() {
+		op, err := operation.ParseOperation(e)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse log kv operation")
+		}
+
+		ops := []operation.Operation{op}
+		if op.GetOperation() == "BATCH" {
+			ops = op.GetOperations()
+		}
+
+		for _, op := range ops {
+			if op.GetKey() != nil && *op.GetKey() == key {
+				history = append(history, op)
+			}
+		}
+	}
+
+	return history, nil
+}
+
+func (o *orbitDBKeyValue) GetAt(ctx context.Context, key string, heads []cid.Cid) ([]byte, error) {
+	view, err := o.At(ctx, heads)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read store at the given heads")
+	}
+
+	value, _ := view.Index().Get(key).([]byte)
+
+	return value, nil
+}
+
+func (o *orbitDBKeyValue) DiffKeys(ctx context.Context, from []cid.Cid, to []cid.Cid) (*iface.KeyValueDiff, error) {
+	fromValues := map[string][]byte{}
+	if len(from) > 0 {
+		view, err := o.At(ctx, from)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to read store at the from heads")
+		}
+
+		fromValues = view.Index().(*kvIndex).all()
+	}
+
+	view, err := o.At(ctx, to)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read store at the to heads")
+	}
+
+	toValues := view.Index().(*kvIndex).all()
+
+	diff := &iface.KeyValueDiff{
+		Added:   map[string][]byte{},
+		Changed: map[string][]byte{},
+		Removed: map[string][]byte{},
+	}
+
+	for k, v := range toValues {
+		if old, ok := fromValues[k]; !ok {
+			diff.Added[k] = v
+		} else if !bytes.Equal(old, v) {
+			diff.Changed[k] = v
+		}
+	}
+
+	for k, v := range fromValues {
+		if _, ok := toValues[k]; !ok {
+			diff.Removed[k] = v
+		}
+	}
+
+	return diff, nil
+}
+
 func (o *orbitDBKeyValue) Type() string {
 	return "keyvalue"
 }
 
+func (o *orbitDBKeyValue) Watch(ctx context.Context, prefix string) <-chan iface.KeyChange {
+	return o.watchers.add(ctx, prefix)
+}
+
+func (o *orbitDBKeyValue) Close() error {
+	if o.stopReaper != nil {
+		o.stopReaper()
+	}
+
+	o.watchers.removeAll()
+
+	return o.BaseStore.Close()
+}
+
+// reap Periodically deletes the expired keys written by the current
+// identity, so the other peers can drop their values
+func (o *orbitDBKeyValue) reap(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		keys := o.Index().(*kvIndex).expiredOwnedKeys()
+		if len(keys) == 0 {
+			continue
+		}
+
+		ops := make([]operation.Operation, len(keys))
+		for i := range keys {
+			ops[i] = operation.NewOperation(&keys[i], "DEL", nil)
+		}
+
+		if _, err := o.Batch(ctx, ops); err != nil {
+			logger().Error("unable to delete expired keys", zap.Error(err))
+		}
+	}
+}
+
 func init() {
 	stores.RegisterStore("keyvalue", NewOrbitDBKeyValue)
 }
@@ -78,13 +288,44 @@ func init() {
 func NewOrbitDBKeyValue(ctx context.Context, ipfs coreapi.CoreAPI, identity *identityprovider.Identity, addr address.Address, options *iface.NewStoreOptions) (i iface.Store, e error) {
 	store := &orbitDBKeyValue{}
 
-	options.Index = NewKVIndex
+	kvOptions, _ := options.StoreSpecificOpts.(*iface.KeyValueStoreOptions)
+	if kvOptions == nil {
+		kvOptions = &iface.KeyValueStoreOptions{}
+	}
+
+	options.Index = func(publicKey []byte) iface.StoreIndex {
+		index := NewKVIndex(publicKey).(*kvIndex)
+		index.resolver = options.ConflictResolver
+		index.multiValue = kvOptions.MultiValue != nil && *kvOptions.MultiValue
+
+		// indexes built for read-only views of the store must not notify
+		index.onChange = func(change iface.KeyChange) {
+			if store.Index() == index {
+				store.watchers.notify(change)
+			}
+		}
+
+		index.onConflict = func(key string, values [][]byte) {
+			if store.Index() == index {
+				store.Emit(stores.NewEventConflict(store.Address(), key, values))
+			}
+		}
+
+		return index
+	}
 
 	err := store.InitBaseStore(ctx, ipfs, identity, addr, options)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to initialize base store")
 	}
 
+	if kvOptions.ReaperInterval != nil {
+		reaperCtx, cancel := context.WithCancel(ctx)
+		store.stopReaper = cancel
+
+		go store.reap(reaperCtx, *kvOptions.ReaperInterval)
+	}
+
 	return store, nil
 }
 
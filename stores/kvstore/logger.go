@@ -0,0 +1,7 @@
+package kvstore
+
+import "go.uber.org/zap"
+
+func logger() *zap.Logger {
+	return zap.L().Named("orbitdb.stores.kvstore")
+}
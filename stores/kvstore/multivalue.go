@@ -0,0 +1,84 @@
+package kvstore
+
+import (
+	"sort"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	"berty.tech/go-orbit-db/stores/conflict"
+	"berty.tech/go-orbit-db/stores/operation"
+)
+
+// kvValue A value of a key which hasn't been superseded by a causally later
+// write, used when the index keeps concurrent values
+type kvValue struct {
+	Clock   *conflict.Clock `json:"clock"`
+	Value   []byte          `json:"value,omitempty"`
+	Deleted bool            `json:"deleted,omitempty"`
+}
+
+// applyMultiValue Adds the value of an operation to the concurrent values of
+// its key, the values which are ancestors of the operation entry are dropped
+func (i *kvIndex) applyMultiValue(log ipfslog.Log, key string, op operation.Operation, clock *conflict.Clock) {
+	e := op.GetEntry()
+	if log == nil || e == nil {
+		return
+	}
+
+	values := []*kvValue{}
+
+	for _, v := range i.values[key] {
+		if v.Clock.Hash == clock.Hash {
+			// replaced by a later operation of the same batch
+			continue
+		}
+
+		if conflict.IsAncestor(log, v.Clock, e) {
+			continue
+		}
+
+		if current, ok := log.GetEntries().Get(v.Clock.Hash); ok && conflict.IsAncestor(log, clock, current) {
+			// the operation has already been superseded
+			return
+		}
+
+		values = append(values, v)
+	}
+
+	values = append(values, &kvValue{
+		Clock:   clock,
+		Value:   op.GetValue(),
+		Deleted: op.GetOperation() == "DEL",
+	})
+
+	sort.Slice(values, func(a, b int) bool {
+		return values[b].Clock.After(values[a].Clock)
+	})
+
+	i.values[key] = values
+
+	if onConflict := i.onConflict; len(values) > 1 && onConflict != nil {
+		conflicting := make([][]byte, len(values))
+		for j, v := range values {
+			conflicting[j] = v.Value
+		}
+
+		i.pending = append(i.pending, func() { onConflict(key, conflicting) })
+	}
+}
+
+// getAll Returns the concurrent values of a key, deletions are omitted, it
+// acquires the lock
+func (i *kvIndex) getAll(key string) [][]byte {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	var res [][]byte
+
+	for _, v := range i.values[key] {
+		if !v.Deleted {
+			res = append(res, v.Value)
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,118 @@
+package kvstore
+
+import (
+	"math/rand"
+)
+
+const (
+	// skipListMaxLevel allows efficient lookups for up to 4^16 keys
+	skipListMaxLevel = 16
+
+	// skipListBranching is the inverse of the probability for a node to be
+	// promoted to the next level
+	skipListBranching = 4
+)
+
+type skipListNode struct {
+	key  string
+	next []*skipListNode
+}
+
+// skipList A sorted set of keys
+type skipList struct {
+	head   *skipListNode
+	level  int
+	length int
+	rand   *rand.Rand
+}
+
+func newSkipList() *skipList {
+	return &skipList{
+		head:  &skipListNode{next: make([]*skipListNode, skipListMaxLevel)},
+		level: 1,
+		rand:  rand.New(rand.NewSource(1)),
+	}
+}
+
+func (s *skipList) randomLevel() int {
+	level := 1
+	for level < skipListMaxLevel && s.rand.Intn(skipListBranching) == 0 {
+		level++
+	}
+
+	return level
+}
+
+// predecessors Returns, for each level, the last node whose key is lower
+// than the given one
+func (s *skipList) predecessors(key string) []*skipListNode {
+	update := make([]*skipListNode, skipListMaxLevel)
+
+	n := s.head
+	for l := s.level - 1; l >= 0; l-- {
+		for n.next[l] != nil && n.next[l].key < key {
+			n = n.next[l]
+		}
+
+		update[l] = n
+	}
+
+	return update
+}
+
+// Insert Adds a key to the list, does nothing if it is already present
+func (s *skipList) Insert(key string) {
+	update := s.predecessors(key)
+
+	if n := update[0].next[0]; n != nil && n.key == key {
+		return
+	}
+
+	level := s.randomLevel()
+	if level > s.level {
+		for l := s.level; l < level; l++ {
+			update[l] = s.head
+		}
+
+		s.level = level
+	}
+
+	n := &skipListNode{key: key, next: make([]*skipListNode, level)}
+	for l := 0; l < level; l++ {
+		n.next[l] = update[l].next[l]
+		update[l].next[l] = n
+	}
+
+	s.length++
+}
+
+// Delete Removes a key from the list, does nothing if it is absent
+func (s *skipList) Delete(key string) {
+	update := s.predecessors(key)
+
+	n := update[0].next[0]
+	if n == nil || n.key != key {
+		return
+	}
+
+	for l := 0; l < len(n.next); l++ {
+		update[l].next[l] = n.next[l]
+	}
+
+	for s.level > 1 && s.head.next[s.level-1] == nil {
+		s.level--
+	}
+
+	s.length--
+}
+
+// Seek Returns the node of the first key greater than or equal to the given
+// one, or nil if there is none
+func (s *skipList) Seek(key string) *skipListNode {
+	return s.predecessors(key)[0].next[0]
+}
+
+// Len Returns the number of keys in the list
+func (s *skipList) Len() int {
+	return s.length
+}
@@ -0,0 +1,135 @@
+package kvstore
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"berty.tech/go-orbit-db/iface"
+)
+
+// kvWatcher Queues the changes of the keys matching its prefix until they are
+// delivered, the queue is unbounded so no change is dropped when the reader
+// is slow
+type kvWatcher struct {
+	prefix string
+	out    chan iface.KeyChange
+
+	lock   sync.Mutex
+	queue  []iface.KeyChange
+	notify chan struct{}
+	done   chan struct{}
+}
+
+func (w *kvWatcher) push(change iface.KeyChange) {
+	w.lock.Lock()
+	w.queue = append(w.queue, change)
+	w.lock.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *kvWatcher) pop() []iface.KeyChange {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	changes := w.queue
+	w.queue = nil
+
+	return changes
+}
+
+// deliver Sends the queued changes in order until the context is done or the
+// watcher is removed, then closes the channel
+func (w *kvWatcher) deliver(ctx context.Context) {
+	defer close(w.out)
+
+	for {
+		for _, change := range w.pop() {
+			select {
+			case w.out <- change:
+			case <-ctx.Done():
+				return
+			case <-w.done:
+				return
+			}
+		}
+
+		select {
+		case <-w.notify:
+		case <-ctx.Done():
+			return
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// kvWatchers Dispatches the changes of the index to the watchers of the
+// matching prefixes
+type kvWatchers struct {
+	lock     sync.Mutex
+	watchers map[*kvWatcher]struct{}
+}
+
+func (w *kvWatchers) add(ctx context.Context, prefix string) <-chan iface.KeyChange {
+	watcher := &kvWatcher{
+		prefix: prefix,
+		out:    make(chan iface.KeyChange),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+
+	w.lock.Lock()
+	if w.watchers == nil {
+		w.watchers = map[*kvWatcher]struct{}{}
+	}
+
+	w.watchers[watcher] = struct{}{}
+	w.lock.Unlock()
+
+	go func() {
+		defer w.remove(watcher)
+
+		watcher.deliver(ctx)
+	}()
+
+	return watcher.out
+}
+
+func (w *kvWatchers) remove(watcher *kvWatcher) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if _, ok := w.watchers[watcher]; !ok {
+		return
+	}
+
+	delete(w.watchers, watcher)
+	close(watcher.done)
+}
+
+func (w *kvWatchers) removeAll() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for watcher := range w.watchers {
+		close(watcher.done)
+	}
+
+	w.watchers = nil
+}
+
+func (w *kvWatchers) notify(change iface.KeyChange) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for watcher := range w.watchers {
+		if strings.HasPrefix(change.Key, watcher.prefix) {
+			watcher.push(change)
+		}
+	}
+}
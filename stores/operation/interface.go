@@ -1,6 +1,8 @@
 package operation
 
 import (
+	"time"
+
 	ipfslog "berty.tech/go-ipfs-log"
 )
 
@@ -15,6 +17,17 @@ type Operation interface {
 	// GetValue Returns the operation payload
 	GetValue() []byte
 
+	// GetExpiry Returns the time after which the operation no longer
+	// applies, if any
+	GetExpiry() *time.Time
+
+	// GetTimestamp Returns the wall-clock time at which the operation was
+	// created, if known
+	GetTimestamp() *time.Time
+
+	// GetOperations Returns the sub-operations of a batch operation
+	GetOperations() []Operation
+
 	// GetEntry Gets the underlying IPFS log Entry
 	GetEntry() ipfslog.Entry
 
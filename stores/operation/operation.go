@@ -3,15 +3,19 @@ package operation
 import (
 	ipfslog "berty.tech/go-ipfs-log"
 	"encoding/json"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 type operation struct {
-	Key   *string       `json:"key,omitempty"`
-	Op    string        `json:"op,omitempty"`
-	Value []byte        `json:"value,omitempty"`
-	Entry ipfslog.Entry `json:"-"`
+	Key       *string       `json:"key,omitempty"`
+	Op        string        `json:"op,omitempty"`
+	Value     []byte        `json:"value,omitempty"`
+	Expires   *int64        `json:"expires,omitempty"`
+	Timestamp *int64        `json:"timestamp,omitempty"`
+	Ops       []*operation  `json:"ops,omitempty"`
+	Entry     ipfslog.Entry `json:"-"`
 }
 
 func (o *operation) Marshal() ([]byte, error) {
@@ -30,6 +34,35 @@ func (o *operation) GetValue() []byte {
 	return o.Value
 }
 
+func (o *operation) GetExpiry() *time.Time {
+	if o.Expires == nil {
+		return nil
+	}
+
+	expiry := time.Unix(0, *o.Expires)
+
+	return &expiry
+}
+
+func (o *operation) GetTimestamp() *time.Time {
+	if o.Timestamp == nil {
+		return nil
+	}
+
+	timestamp := time.Unix(0, *o.Timestamp)
+
+	return &timestamp
+}
+
+func (o *operation) GetOperations() []Operation {
+	ops := make([]Operation, len(o.Ops))
+	for i, op := range o.Ops {
+		ops[i] = op
+	}
+
+	return ops
+}
+
 func (o *operation) GetEntry() ipfslog.Entry {
 	return o.Entry
 }
@@ -48,6 +81,9 @@ func ParseOperation(e ipfslog.Entry) (Operation, error) {
 	}
 
 	op.Entry = e
+	for _, sub := range op.Ops {
+		sub.Entry = e
+	}
 
 	return &op, nil
 }
@@ -61,4 +97,66 @@ func NewOperation(key *string, op string, value []byte) Operation {
 	}
 }
 
+// NewOperationWithExpiry Creates a new operation which stops applying after
+// the given time
+func NewOperationWithExpiry(key *string, op string, value []byte, expiry time.Time) Operation {
+	return &operation{
+		Key:     key,
+		Op:      op,
+		Value:   value,
+		Expires: unixNano(&expiry),
+	}
+}
+
+// NewBatchOperation Creates a new operation grouping the given ones, so they
+// are appended as a single entry
+func NewBatchOperation(ops []Operation) Operation {
+	batch := &operation{
+		Op:  "BATCH",
+		Ops: make([]*operation, len(ops)),
+	}
+
+	for i, op := range ops {
+		batch.Ops[i] = &operation{
+			Key:       op.GetKey(),
+			Op:        op.GetOperation(),
+			Value:     op.GetValue(),
+			Expires:   unixNano(op.GetExpiry()),
+			Timestamp: unixNano(op.GetTimestamp()),
+		}
+	}
+
+	return batch
+}
+
+// WithTimestamp Returns a copy of the operation recording the given
+// wall-clock time, the sub-operations of a batch are stamped as well, the
+// timestamp is only needed by the features relying on it as it makes the
+// payloads larger
+func WithTimestamp(op Operation, t time.Time) Operation {
+	stamped := &operation{
+		Key:       op.GetKey(),
+		Op:        op.GetOperation(),
+		Value:     op.GetValue(),
+		Expires:   unixNano(op.GetExpiry()),
+		Timestamp: unixNano(&t),
+	}
+
+	for _, sub := range op.GetOperations() {
+		stamped.Ops = append(stamped.Ops, WithTimestamp(sub, t).(*operation))
+	}
+
+	return stamped
+}
+
+func unixNano(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+
+	ns := t.UnixNano()
+
+	return &ns
+}
+
 var _ Operation = &operation{}
@@ -14,6 +14,7 @@ import (
 // storeInterface An interface used to avoid import cycles
 type storeInterface interface {
 	OpLog() ipfslog.Log
+	HasEntry(hash cid.Cid) bool
 	IPFS() coreapi.CoreAPI
 	Identity() *identityprovider.Identity
 	AccessController() accesscontroller.Interface
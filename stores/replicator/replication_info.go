@@ -1,6 +1,9 @@
 package replicator
 
+import "sync"
+
 type replicationInfo struct {
+	lock     sync.RWMutex
 	Progress int
 	Max      int
 	Buffered int
@@ -8,38 +11,65 @@ type replicationInfo struct {
 }
 
 func (r *replicationInfo) SetBuffered(i int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	r.Buffered = i
 }
 
 func (r *replicationInfo) SetProgress(i int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	r.Progress = i
 }
 
 func (r *replicationInfo) SetMax(i int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	r.Max = i
 }
 
 func (r *replicationInfo) IncQueued() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	r.Queued++
 }
 
 func (r *replicationInfo) GetProgress() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
 	return r.Progress
 }
 
 func (r *replicationInfo) GetMax() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
 	return r.Max
 }
 
 func (r *replicationInfo) GetBuffered() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
 	return r.Buffered
 }
 
 func (r *replicationInfo) GetQueued() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
 	return r.Queued
 }
 
 func (r *replicationInfo) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	r.Progress = 0
 	r.Max = 0
 	r.Buffered = 0
@@ -47,6 +77,9 @@ func (r *replicationInfo) Reset() {
 }
 
 func (r *replicationInfo) DecreaseQueued(amount int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
 	r.Queued -= amount
 }
 
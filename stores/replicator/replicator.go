@@ -4,6 +4,7 @@ package replicator
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	ipfslog "berty.tech/go-ipfs-log"
@@ -18,18 +19,25 @@ var batchSize = 1
 type replicator struct {
 	events.EventEmitter
 
-	cancelFunc          context.CancelFunc
-	store               storeInterface
+	cancelFunc  context.CancelFunc
+	store       storeInterface
+	concurrency uint
+
+	// lock Protects the queue, the buffer and the statistics, it must not be
+	// held while calling the store or emitting events
+	lock                sync.RWMutex
 	fetching            map[string]cid.Cid
 	statsTasksRequested uint
 	statsTasksStarted   uint
 	statsTasksProcessed uint
 	buffer              []ipfslog.Log
-	concurrency         uint
 	queue               map[string]cid.Cid
 }
 
 func (r *replicator) GetBufferLen() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
 	return len(r.buffer)
 }
 
@@ -38,26 +46,24 @@ func (r *replicator) Stop() {
 }
 
 func (r *replicator) GetQueue() []cid.Cid {
-	var queue []cid.Cid
+	r.lock.RLock()
+	defer r.lock.RUnlock()
 
-	for _, c := range r.queue {
-		queue = append(queue, c)
-	}
-
-	return queue
+	return r.queueSlice()
 }
 
 func (r *replicator) Load(ctx context.Context, cids []cid.Cid) {
 	for _, h := range cids {
-		inLog := r.store.OpLog().GetEntries().UnsafeGet(h.String()) != nil
+		inLog := r.store.HasEntry(h)
+
+		r.lock.Lock()
 		_, fetching := r.fetching[h.String()]
 		_, queued := r.queue[h.String()]
 
-		if fetching || queued || inLog {
-			continue
+		if !fetching && !queued && !inLog {
+			r.addToQueue(h)
 		}
-
-		r.addToQueue(h)
+		r.lock.Unlock()
 	}
 
 	r.processQueue(ctx)
@@ -83,8 +89,13 @@ func NewReplicator(ctx context.Context, store storeInterface, concurrency uint)
 		for {
 			select {
 			case <-time.After(time.Second * 3):
-				if r.tasksRunning() == 0 && len(r.queue) > 0 {
-					logger().Debug(fmt.Sprintf("Had to flush the queue! %d items in the queue, %d %d tasks requested/finished", len(r.queue), r.tasksRequested(), r.tasksFinished()))
+				r.lock.RLock()
+				queueLen, tasksRunning := len(r.queue), r.tasksRunning()
+				tasksRequested, tasksFinished := r.tasksRequested(), r.tasksFinished()
+				r.lock.RUnlock()
+
+				if tasksRunning == 0 && queueLen > 0 {
+					logger().Debug(fmt.Sprintf("Had to flush the queue! %d items in the queue, %d %d tasks requested/finished", queueLen, tasksRequested, tasksFinished))
 					r.processQueue(ctx)
 				}
 			case <-ctx.Done():
@@ -96,6 +107,8 @@ func NewReplicator(ctx context.Context, store storeInterface, concurrency uint)
 	return &r
 }
 
+// tasksRunning Returns the number of tasks started but not processed, the
+// lock must be held, as for the other statistics and queue helpers
 func (r *replicator) tasksRunning() uint {
 	return r.statsTasksStarted - r.statsTasksProcessed
 }
@@ -119,19 +132,22 @@ func (r *replicator) queueSlice() []cid.Cid {
 }
 
 func (r *replicator) processOne(ctx context.Context, h cid.Cid) ([]cid.Cid, error) {
+	hasEntry := r.store.HasEntry(h)
+
+	r.lock.Lock()
 	_, isFetching := r.fetching[h.String()]
-	_, hasEntry := r.store.OpLog().Values().Get(h.String())
 
 	if hasEntry || isFetching {
+		r.lock.Unlock()
 		return nil, nil
 	}
 
 	r.fetching[h.String()] = h
+	r.statsTasksStarted++
+	r.lock.Unlock()
 
 	r.Emit(NewEventLoadAdded(h))
 
-	r.statsTasksStarted++
-
 	l, err := ipfslog.NewFromEntryHash(ctx, r.store.IPFS(), r.store.Identity(), h, &ipfslog.LogOptions{
 		ID:               r.store.OpLog().GetID(),
 		AccessController: r.store.AccessController(),
@@ -144,17 +160,21 @@ func (r *replicator) processOne(ctx context.Context, h cid.Cid) ([]cid.Cid, erro
 	}
 
 	var logToAppend ipfslog.Log = l
-	r.buffer = append(r.buffer, logToAppend)
 
 	latest := l.Values().At(0)
 
+	r.lock.Lock()
+	r.buffer = append(r.buffer, logToAppend)
+	bufferLen := len(r.buffer)
+
 	delete(r.queue, h.String())
 
 	// Mark this task as processed
 	r.statsTasksProcessed++
+	r.lock.Unlock()
 
 	// Notify subscribers that we made progress
-	r.Emit(NewEventLoadProgress("", h, latest, nil, len(r.buffer))) // TODO JS: this._id should be undefined
+	r.Emit(NewEventLoadProgress("", h, latest, nil, bufferLen)) // TODO JS: this._id should be undefined
 
 	var nextValues []cid.Cid
 
@@ -169,7 +189,10 @@ func (r *replicator) processOne(ctx context.Context, h cid.Cid) ([]cid.Cid, erro
 }
 
 func (r *replicator) processQueue(ctx context.Context) {
+	r.lock.Lock()
+
 	if r.tasksRunning() >= r.concurrency {
+		r.lock.Unlock()
 		return
 	}
 
@@ -185,8 +208,13 @@ func (r *replicator) processQueue(ctx context.Context) {
 		items[h.String()] = h
 	}
 
+	r.lock.Unlock()
+
 	for _, e := range items {
+		r.lock.Lock()
 		delete(r.queue, e.String())
+		r.lock.Unlock()
+
 		hashes, err := r.processOne(ctx, e)
 		if err != nil {
 			log.Errorf("unable to get data to process %v", err)
@@ -197,12 +225,18 @@ func (r *replicator) processQueue(ctx context.Context) {
 	}
 
 	for _, hashes := range hashesList {
+		var logs []ipfslog.Log
+
+		r.lock.Lock()
 		if (len(items) > 0 && len(r.buffer) > 0) ||
 			(r.tasksRunning() == 0 && len(r.buffer) > 0) {
 
-			logs := r.buffer
+			logs = r.buffer
 			r.buffer = []ipfslog.Log{}
+		}
+		r.lock.Unlock()
 
+		if logs != nil {
 			logger().Debug(fmt.Sprintf("load end logs, logs found :%d", len(logs)))
 
 			r.Emit(NewEventLoadEnd(logs))
@@ -0,0 +1,37 @@
+package replicator
+
+import (
+	"context"
+
+	"berty.tech/go-orbit-db/events"
+)
+
+// The channels returned by the following functions are closed once the
+// context is done or the replicator listeners are removed.
+
+// SubscribeLoadAdded Returns a channel receiving the hashes the replicator
+// starts fetching
+func SubscribeLoadAdded(ctx context.Context, emitter events.EmitterInterface) <-chan *EventLoadAdded {
+	ch := make(chan *EventLoadAdded)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeLoadProgress Returns a channel receiving an event each time the
+// replicator has fetched an entry
+func SubscribeLoadProgress(ctx context.Context, emitter events.EmitterInterface) <-chan *EventLoadProgress {
+	ch := make(chan *EventLoadProgress)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeLoadEnd Returns a channel receiving the logs fetched by the
+// replicator
+func SubscribeLoadEnd(ctx context.Context, emitter events.EmitterInterface) <-chan *EventLoadEnd {
+	ch := make(chan *EventLoadEnd)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
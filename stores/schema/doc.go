@@ -0,0 +1,2 @@
+// schema validates the values written to the stores against a JSON Schema
+package schema // import "berty.tech/go-orbit-db/stores/schema"
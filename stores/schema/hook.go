@@ -0,0 +1,59 @@
+package schema
+
+import (
+	"context"
+
+	"berty.tech/go-orbit-db/iface"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/pkg/errors"
+)
+
+// storeTypes The types of the stores whose values are JSON documents, the
+// other stores carry counters or opaque payloads which can't be validated
+var storeTypes = map[string]struct{}{
+	"keyvalue": {},
+	"docstore": {},
+}
+
+// SupportsStoreType Checks whether the values of a store type can be
+// validated against a schema
+func SupportsStoreType(storeType string) bool {
+	_, ok := storeTypes[storeType]
+	return ok
+}
+
+// ValidateHook Returns a validate hook checking that the values carried by
+// the operations match the schema, operations without a value, ie.
+// deletions, are always accepted
+func ValidateHook(s *Schema) iface.ValidateHook {
+	return func(_ context.Context, op operation.Operation) error {
+		return s.validateOperation(op)
+	}
+}
+
+func (s *Schema) validateOperation(op operation.Operation) error {
+	if ops := op.GetOperations(); len(ops) > 0 {
+		for _, sub := range ops {
+			if err := s.validateOperation(sub); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	value := op.GetValue()
+	if value == nil {
+		return nil
+	}
+
+	if err := s.ValidateJSON(value); err != nil {
+		if key := op.GetKey(); key != nil {
+			return errors.Wrapf(err, "invalid value for key %s", *key)
+		}
+
+		return errors.Wrap(err, "invalid value")
+	}
+
+	return nil
+}
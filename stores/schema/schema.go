@@ -0,0 +1,563 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// Schema A parsed JSON Schema, only a subset of the keywords is supported:
+// type, enum, const, properties, required, additionalProperties, items,
+// minItems, maxItems, minLength, maxLength, pattern, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, allOf, anyOf, oneOf and not, along with
+// the annotations which don't constrain values. Schemas using any other
+// keyword are rejected, so a constraint is never silently ignored.
+type Schema struct {
+	reject bool
+
+	types    []string
+	enum     []interface{}
+	hasConst bool
+	constant interface{}
+
+	properties             map[string]*Schema
+	required               []string
+	additionalProperties   *Schema
+	noAdditionalProperties bool
+
+	items    *Schema
+	minItems *int
+	maxItems *int
+
+	minLength *int
+	maxLength *int
+	pattern   *regexp.Regexp
+
+	minimum          *float64
+	maximum          *float64
+	exclusiveMinimum *float64
+	exclusiveMaximum *float64
+
+	allOf []*Schema
+	anyOf []*Schema
+	oneOf []*Schema
+	not   *Schema
+}
+
+// supportedKeywords The keywords accepted by Parse, annotations are accepted
+// as they don't constrain values
+var supportedKeywords = map[string]struct{}{
+	"type":                 {},
+	"enum":                 {},
+	"const":                {},
+	"properties":           {},
+	"required":             {},
+	"additionalProperties": {},
+	"items":                {},
+	"minItems":             {},
+	"maxItems":             {},
+	"minLength":            {},
+	"maxLength":            {},
+	"pattern":              {},
+	"minimum":              {},
+	"maximum":              {},
+	"exclusiveMinimum":     {},
+	"exclusiveMaximum":     {},
+	"allOf":                {},
+	"anyOf":                {},
+	"oneOf":                {},
+	"not":                  {},
+
+	"$schema":     {},
+	"$id":         {},
+	"$comment":    {},
+	"title":       {},
+	"description": {},
+	"default":     {},
+	"examples":    {},
+}
+
+var knownTypes = map[string]struct{}{
+	"null":    {},
+	"boolean": {},
+	"object":  {},
+	"array":   {},
+	"number":  {},
+	"integer": {},
+	"string":  {},
+}
+
+// Parse Parses a JSON Schema document
+func Parse(data []byte) (*Schema, error) {
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal schema")
+	}
+
+	return parse(doc, "#")
+}
+
+func parse(doc interface{}, at string) (*Schema, error) {
+	switch doc := doc.(type) {
+	case bool:
+		return &Schema{reject: !doc}, nil
+
+	case map[string]interface{}:
+		return parseObject(doc, at)
+
+	default:
+		return nil, errors.Errorf("%s: a schema must be an object or a boolean", at)
+	}
+}
+
+func parseObject(doc map[string]interface{}, at string) (*Schema, error) {
+	// sorted to report errors in a deterministic order
+	keywords := make([]string, 0, len(doc))
+	for keyword := range doc {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+	for _, keyword := range keywords {
+		if _, ok := supportedKeywords[keyword]; !ok {
+			return nil, errors.Errorf("%s: keyword %s is not supported", at, keyword)
+		}
+	}
+
+	s := &Schema{}
+	var err error
+
+	if s.types, err = parseTypes(doc["type"], at); err != nil {
+		return nil, err
+	}
+
+	if v, ok := doc["enum"]; ok {
+		if s.enum, ok = v.([]interface{}); !ok {
+			return nil, errors.Errorf("%s: enum must be an array", at)
+		}
+	}
+
+	s.constant, s.hasConst = doc["const"]
+
+	if v, ok := doc["properties"]; ok {
+		props, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("%s: properties must be an object", at)
+		}
+
+		s.properties = make(map[string]*Schema, len(props))
+		for name, prop := range props {
+			if s.properties[name], err = parse(prop, at+"/properties/"+name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if v, ok := doc["required"]; ok {
+		names, ok := v.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("%s: required must be an array", at)
+		}
+
+		for _, name := range names {
+			str, ok := name.(string)
+			if !ok {
+				return nil, errors.Errorf("%s: required must only contain strings", at)
+			}
+
+			s.required = append(s.required, str)
+		}
+	}
+
+	if v, ok := doc["additionalProperties"]; ok {
+		if allowed, ok := v.(bool); ok {
+			s.noAdditionalProperties = !allowed
+		} else if s.additionalProperties, err = parse(v, at+"/additionalProperties"); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := doc["items"]; ok {
+		if s.items, err = parse(v, at+"/items"); err != nil {
+			return nil, err
+		}
+	}
+
+	for keyword, dest := range map[string]**int{
+		"minItems":  &s.minItems,
+		"maxItems":  &s.maxItems,
+		"minLength": &s.minLength,
+		"maxLength": &s.maxLength,
+	} {
+		if *dest, err = parseCount(doc, keyword, at); err != nil {
+			return nil, err
+		}
+	}
+
+	for keyword, dest := range map[string]**float64{
+		"minimum":          &s.minimum,
+		"maximum":          &s.maximum,
+		"exclusiveMinimum": &s.exclusiveMinimum,
+		"exclusiveMaximum": &s.exclusiveMaximum,
+	} {
+		if *dest, err = parseNumber(doc, keyword, at); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := doc["pattern"]; ok {
+		str, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("%s: pattern must be a string", at)
+		}
+
+		if s.pattern, err = regexp.Compile(str); err != nil {
+			return nil, errors.Wrapf(err, "%s: invalid pattern", at)
+		}
+	}
+
+	for keyword, dest := range map[string]*[]*Schema{
+		"allOf": &s.allOf,
+		"anyOf": &s.anyOf,
+		"oneOf": &s.oneOf,
+	} {
+		if *dest, err = parseList(doc, keyword, at); err != nil {
+			return nil, err
+		}
+	}
+
+	if v, ok := doc["not"]; ok {
+		if s.not, err = parse(v, at+"/not"); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func parseTypes(v interface{}, at string) ([]string, error) {
+	var types []string
+
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+
+	case string:
+		types = []string{v}
+
+	case []interface{}:
+		for _, t := range v {
+			str, ok := t.(string)
+			if !ok {
+				return nil, errors.Errorf("%s: type must only contain strings", at)
+			}
+
+			types = append(types, str)
+		}
+
+	default:
+		return nil, errors.Errorf("%s: type must be a string or an array", at)
+	}
+
+	for _, t := range types {
+		if _, ok := knownTypes[t]; !ok {
+			return nil, errors.Errorf("%s: unknown type %s", at, t)
+		}
+	}
+
+	return types, nil
+}
+
+func parseCount(doc map[string]interface{}, keyword string, at string) (*int, error) {
+	v, ok := doc[keyword]
+	if !ok {
+		return nil, nil
+	}
+
+	n, ok := v.(float64)
+	if !ok || n < 0 || n != math.Trunc(n) {
+		return nil, errors.Errorf("%s: %s must be a positive integer", at, keyword)
+	}
+
+	count := int(n)
+
+	return &count, nil
+}
+
+func parseNumber(doc map[string]interface{}, keyword string, at string) (*float64, error) {
+	v, ok := doc[keyword]
+	if !ok {
+		return nil, nil
+	}
+
+	n, ok := v.(float64)
+	if !ok {
+		return nil, errors.Errorf("%s: %s must be a number", at, keyword)
+	}
+
+	return &n, nil
+}
+
+func parseList(doc map[string]interface{}, keyword string, at string) ([]*Schema, error) {
+	v, ok := doc[keyword]
+	if !ok {
+		return nil, nil
+	}
+
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, errors.Errorf("%s: %s must be a non-empty array", at, keyword)
+	}
+
+	schemas := make([]*Schema, len(list))
+	for i, item := range list {
+		var err error
+		if schemas[i], err = parse(item, fmt.Sprintf("%s/%s/%d", at, keyword, i)); err != nil {
+			return nil, err
+		}
+	}
+
+	return schemas, nil
+}
+
+// ValidateJSON Checks that a JSON document matches the schema
+func (s *Schema) ValidateJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return errors.Wrap(err, "value is not valid JSON")
+	}
+
+	return s.Validate(value)
+}
+
+// Validate Checks that a value, as decoded by encoding/json into an
+// interface{}, matches the schema
+func (s *Schema) Validate(value interface{}) error {
+	return s.validate(value, "$")
+}
+
+func (s *Schema) validate(value interface{}, at string) error {
+	if s.reject {
+		return errors.Errorf("%s: no value is allowed", at)
+	}
+
+	if len(s.types) > 0 && !matchesType(value, s.types) {
+		return errors.Errorf("%s: expected %s, got %s", at, strings.Join(s.types, " or "), typeOf(value))
+	}
+
+	if s.enum != nil {
+		found := false
+		for _, allowed := range s.enum {
+			if reflect.DeepEqual(value, allowed) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return errors.Errorf("%s: value is not one of the allowed values", at)
+		}
+	}
+
+	if s.hasConst && !reflect.DeepEqual(value, s.constant) {
+		return errors.Errorf("%s: value doesn't match the expected constant", at)
+	}
+
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if err := s.validateObject(value, at); err != nil {
+			return err
+		}
+
+	case []interface{}:
+		if err := s.validateArray(value, at); err != nil {
+			return err
+		}
+
+	case string:
+		if err := s.validateString(value, at); err != nil {
+			return err
+		}
+
+	case float64:
+		if err := s.validateNumber(value, at); err != nil {
+			return err
+		}
+	}
+
+	return s.validateCombinations(value, at)
+}
+
+func (s *Schema) validateObject(value map[string]interface{}, at string) error {
+	for _, name := range s.required {
+		if _, ok := value[name]; !ok {
+			return errors.Errorf("%s: missing required property %s", at, name)
+		}
+	}
+
+	// sorted to report errors in a deterministic order
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propAt := at + "." + name
+
+		if prop, ok := s.properties[name]; ok {
+			if err := prop.validate(value[name], propAt); err != nil {
+				return err
+			}
+		} else if s.noAdditionalProperties {
+			return errors.Errorf("%s: additional property %s is not allowed", at, name)
+		} else if s.additionalProperties != nil {
+			if err := s.additionalProperties.validate(value[name], propAt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateArray(value []interface{}, at string) error {
+	if s.minItems != nil && len(value) < *s.minItems {
+		return errors.Errorf("%s: expected at least %d items", at, *s.minItems)
+	}
+
+	if s.maxItems != nil && len(value) > *s.maxItems {
+		return errors.Errorf("%s: expected at most %d items", at, *s.maxItems)
+	}
+
+	if s.items != nil {
+		for i, item := range value {
+			if err := s.items.validate(item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateString(value string, at string) error {
+	length := utf8.RuneCountInString(value)
+
+	if s.minLength != nil && length < *s.minLength {
+		return errors.Errorf("%s: expected at least %d characters", at, *s.minLength)
+	}
+
+	if s.maxLength != nil && length > *s.maxLength {
+		return errors.Errorf("%s: expected at most %d characters", at, *s.maxLength)
+	}
+
+	if s.pattern != nil && !s.pattern.MatchString(value) {
+		return errors.Errorf("%s: value doesn't match pattern %s", at, s.pattern.String())
+	}
+
+	return nil
+}
+
+func (s *Schema) validateNumber(value float64, at string) error {
+	if s.minimum != nil && value < *s.minimum {
+		return errors.Errorf("%s: expected a value greater than or equal to %v", at, *s.minimum)
+	}
+
+	if s.maximum != nil && value > *s.maximum {
+		return errors.Errorf("%s: expected a value lower than or equal to %v", at, *s.maximum)
+	}
+
+	if s.exclusiveMinimum != nil && value <= *s.exclusiveMinimum {
+		return errors.Errorf("%s: expected a value greater than %v", at, *s.exclusiveMinimum)
+	}
+
+	if s.exclusiveMaximum != nil && value >= *s.exclusiveMaximum {
+		return errors.Errorf("%s: expected a value lower than %v", at, *s.exclusiveMaximum)
+	}
+
+	return nil
+}
+
+func (s *Schema) validateCombinations(value interface{}, at string) error {
+	for _, sub := range s.allOf {
+		if err := sub.validate(value, at); err != nil {
+			return err
+		}
+	}
+
+	if s.anyOf != nil {
+		matched := false
+		for _, sub := range s.anyOf {
+			if sub.validate(value, at) == nil {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			return errors.Errorf("%s: value doesn't match any of the anyOf schemas", at)
+		}
+	}
+
+	if s.oneOf != nil {
+		matched := 0
+		for _, sub := range s.oneOf {
+			if sub.validate(value, at) == nil {
+				matched++
+			}
+		}
+
+		if matched != 1 {
+			return errors.Errorf("%s: value matches %d of the oneOf schemas instead of one", at, matched)
+		}
+	}
+
+	if s.not != nil && s.not.validate(value, at) == nil {
+		return errors.Errorf("%s: value matches the not schema", at)
+	}
+
+	return nil
+}
+
+func matchesType(value interface{}, types []string) bool {
+	actual := typeOf(value)
+
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func typeOf(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		if value == math.Trunc(value) && !math.IsInf(value, 0) {
+			return "integer"
+		}
+
+		return "number"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
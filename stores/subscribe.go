@@ -0,0 +1,90 @@
+package stores
+
+import (
+	"context"
+
+	"berty.tech/go-orbit-db/events"
+)
+
+// The channels returned by the following functions are closed once the
+// context is done or the store is closed.
+
+// SubscribeReplicateProgress Returns a channel receiving the replication
+// progress of a store
+func SubscribeReplicateProgress(ctx context.Context, emitter events.EmitterInterface) <-chan *EventReplicateProgress {
+	ch := make(chan *EventReplicateProgress)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeReplicated Returns a channel receiving an event each time a store
+// has replicated entries
+func SubscribeReplicated(ctx context.Context, emitter events.EmitterInterface) <-chan *EventReplicated {
+	ch := make(chan *EventReplicated)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeLoad Returns a channel receiving an event each time a store starts
+// loading its content
+func SubscribeLoad(ctx context.Context, emitter events.EmitterInterface) <-chan *EventLoad {
+	ch := make(chan *EventLoad)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeReady Returns a channel receiving an event each time a store has
+// loaded its content
+func SubscribeReady(ctx context.Context, emitter events.EmitterInterface) <-chan *EventReady {
+	ch := make(chan *EventReady)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeWrite Returns a channel receiving the entries written locally to a
+// store
+func SubscribeWrite(ctx context.Context, emitter events.EmitterInterface) <-chan *EventWrite {
+	ch := make(chan *EventWrite)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeConflict Returns a channel receiving the conflicts detected by a
+// store keeping concurrent values
+func SubscribeConflict(ctx context.Context, emitter events.EmitterInterface) <-chan *EventConflict {
+	ch := make(chan *EventConflict)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeInvalidEntry Returns a channel receiving the replicated entries
+// rejected by a store
+func SubscribeInvalidEntry(ctx context.Context, emitter events.EmitterInterface) <-chan *EventInvalidEntry {
+	ch := make(chan *EventInvalidEntry)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeClosed Returns a channel receiving an event when a store is closed
+func SubscribeClosed(ctx context.Context, emitter events.EmitterInterface) <-chan *EventClosed {
+	ch := make(chan *EventClosed)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
+
+// SubscribeNewPeer Returns a channel receiving the peers discovered on the
+// pubsub channel of a store
+func SubscribeNewPeer(ctx context.Context, emitter events.EmitterInterface) <-chan *EventNewPeer {
+	ch := make(chan *EventNewPeer)
+	events.SubscribeChan(ctx, emitter, ch)
+
+	return ch
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	orbitdb "berty.tech/go-orbit-db"
+	ipfspath "github.com/ipfs/interface-go-ipfs-core/path"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCARExport(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	dbPath := "./orbitdb/tests/car"
+	infinity := -1
+	entryCount := 20
+
+	defer os.RemoveAll(dbPath)
+
+	Convey("orbit-db - CAR export and import", t, FailureHalts, func(c C) {
+		err := os.RemoveAll(dbPath)
+		c.So(err, ShouldBeNil)
+
+		db1Path := path.Join(dbPath, "1")
+		db2Path := path.Join(dbPath, "2")
+
+		// nodes are not connected, blocks can only come from the CAR file
+		_, ipfs1 := MakeIPFS(ctx, t)
+		_, ipfs2 := MakeIPFS(ctx, t)
+
+		orbitdb1, err := orbitdb.NewOrbitDB(ctx, ipfs1, &orbitdb.NewOrbitDBOptions{Directory: &db1Path})
+		c.So(err, ShouldBeNil)
+		defer orbitdb1.Close()
+
+		orbitdb2, err := orbitdb.NewOrbitDB(ctx, ipfs2, &orbitdb.NewOrbitDBOptions{Directory: &db2Path})
+		c.So(err, ShouldBeNil)
+		defer orbitdb2.Close()
+
+		db1, err := orbitdb1.Log(ctx, "car-export", nil)
+		c.So(err, ShouldBeNil)
+
+		for i := 0; i < entryCount; i++ {
+			_, err := db1.Add(ctx, []byte(fmt.Sprintf("hello%d", i)))
+			c.So(err, ShouldBeNil)
+		}
+
+		buf := &bytes.Buffer{}
+		err = db1.ExportCAR(ctx, buf)
+		c.So(err, ShouldBeNil)
+
+		c.Convey("opens the exported store on another node", FailureHalts, func(c C) {
+			store, err := orbitdb2.ImportCAR(ctx, bytes.NewReader(buf.Bytes()), nil)
+			c.So(err, ShouldBeNil)
+			c.So(store.Address().String(), ShouldEqual, db1.Address().String())
+
+			db2, ok := store.(orbitdb.EventLogStore)
+			c.So(ok, ShouldBeTrue)
+
+			items, err := db2.List(ctx, &orbitdb.StreamOptions{Amount: &infinity})
+			c.So(err, ShouldBeNil)
+			c.So(len(items), ShouldEqual, entryCount)
+			c.So(string(items[0].GetValue()), ShouldEqual, "hello0")
+			c.So(string(items[entryCount-1].GetValue()), ShouldEqual, fmt.Sprintf("hello%d", entryCount-1))
+		})
+
+		c.Convey("refuses a CAR file from another store", FailureHalts, func(c C) {
+			other, err := orbitdb1.Log(ctx, "car-export-other", nil)
+			c.So(err, ShouldBeNil)
+			defer other.Drop()
+
+			err = other.ImportCAR(ctx, bytes.NewReader(buf.Bytes()))
+			c.So(err, ShouldNotBeNil)
+		})
+
+		c.Convey("refuses blocks not matching their CID without storing them", FailureHalts, func(c C) {
+			stat, err := ipfs1.Block().Put(ctx, bytes.NewReader([]byte("original")))
+			c.So(err, ShouldBeNil)
+
+			claimed := stat.Path().Cid()
+			tampered := []byte("tampered")
+
+			tamperedCID, err := claimed.Prefix().Sum(tampered)
+			c.So(err, ShouldBeNil)
+
+			// keeps the header of the export, followed by a single tampered block
+			headerSize, n := binary.Uvarint(buf.Bytes())
+			c.So(n, ShouldBeGreaterThan, 0)
+
+			car := &bytes.Buffer{}
+			car.Write(buf.Bytes()[:n+int(headerSize)])
+
+			section := append(claimed.Bytes(), tampered...)
+			sizeBuf := make([]byte, binary.MaxVarintLen64)
+			car.Write(sizeBuf[:binary.PutUvarint(sizeBuf, uint64(len(section)))])
+			car.Write(section)
+
+			_, err = orbitdb2.ImportCAR(ctx, bytes.NewReader(car.Bytes()), nil)
+			c.So(err, ShouldNotBeNil)
+
+			statCtx, statCancel := context.WithTimeout(ctx, time.Millisecond*500)
+			defer statCancel()
+
+			_, err = ipfs2.Block().Stat(statCtx, ipfspath.IpfsPath(tamperedCID))
+			c.So(err, ShouldNotBeNil)
+		})
+
+		TeardownNetwork()
+	})
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	orbitdb "berty.tech/go-orbit-db"
+	"berty.tech/go-orbit-db/accesscontroller"
+	peerstore "github.com/libp2p/go-libp2p-peerstore"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConcurrentWrites(t *testing.T) {
+	Convey("orbit-db - Concurrent writes", t, FailureHalts, func(c C) {
+		const writers = 4
+		const writesPerWriter = 25
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*180)
+		defer cancel()
+
+		dbPath1 := "./orbitdb/tests/concurrency/1"
+		dbPath2 := "./orbitdb/tests/concurrency/2"
+
+		defer os.RemoveAll("./orbitdb/tests/concurrency/")
+
+		ipfsd1, ipfs1 := MakeIPFS(ctx, t)
+		ipfsd2, ipfs2 := MakeIPFS(ctx, t)
+
+		_, err := TestNetwork.LinkPeers(ipfsd1.Identity, ipfsd2.Identity)
+		c.So(err, ShouldBeNil)
+
+		peerInfo2 := peerstore.PeerInfo{ID: ipfsd2.Identity, Addrs: ipfsd2.PeerHost.Addrs()}
+		err = ipfs1.Swarm().Connect(ctx, peerInfo2)
+		c.So(err, ShouldBeNil)
+
+		peerInfo1 := peerstore.PeerInfo{ID: ipfsd1.Identity, Addrs: ipfsd1.PeerHost.Addrs()}
+		err = ipfs2.Swarm().Connect(ctx, peerInfo1)
+		c.So(err, ShouldBeNil)
+
+		orbitdb1, err := orbitdb.NewOrbitDB(ctx, ipfs1, &orbitdb.NewOrbitDBOptions{Directory: &dbPath1})
+		c.So(err, ShouldBeNil)
+
+		orbitdb2, err := orbitdb.NewOrbitDB(ctx, ipfs2, &orbitdb.NewOrbitDBOptions{Directory: &dbPath2})
+		c.So(err, ShouldBeNil)
+
+		access := &accesscontroller.CreateAccessControllerOptions{
+			Access: map[string][]string{
+				"write": {
+					orbitdb1.Identity().ID,
+					orbitdb2.Identity().ID,
+				},
+			},
+		}
+
+		db1, err := orbitdb1.KeyValue(ctx, "concurrency-tests", &orbitdb.CreateDBOptions{
+			Directory:        &dbPath1,
+			AccessController: access,
+		})
+		c.So(err, ShouldBeNil)
+
+		db2, err := orbitdb2.KeyValue(ctx, db1.Address().String(), &orbitdb.CreateDBOptions{
+			Directory:        &dbPath2,
+			AccessController: access,
+		})
+		c.So(err, ShouldBeNil)
+
+		c.Convey("replicates values written concurrently on both peers", FailureHalts, func(c C) {
+			wg := sync.WaitGroup{}
+			errs := make(chan error, 2*writers*writesPerWriter)
+
+			for i, db := range []orbitdb.KeyValueStore{db1, db2} {
+				for w := 0; w < writers; w++ {
+					wg.Add(1)
+
+					go func(db orbitdb.KeyValueStore, prefix string) {
+						defer wg.Done()
+
+						for n := 0; n < writesPerWriter; n++ {
+							if _, err := db.Put(ctx, fmt.Sprintf("%s-%d", prefix, n), []byte("value")); err != nil {
+								errs <- err
+							}
+
+							_ = db.All()
+							_, _ = db.Get(ctx, prefix)
+						}
+					}(db, fmt.Sprintf("peer%d-writer%d", i, w))
+				}
+			}
+
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				c.So(err, ShouldBeNil)
+			}
+
+			c.So(len(db1.All()), ShouldBeGreaterThanOrEqualTo, writers*writesPerWriter)
+			c.So(len(db2.All()), ShouldBeGreaterThanOrEqualTo, writers*writesPerWriter)
+
+			expected := 2 * writers * writesPerWriter
+
+			for i := 0; i < 100 && (len(db1.All()) < expected || len(db2.All()) < expected); i++ {
+				<-time.After(time.Millisecond * 100)
+			}
+
+			c.So(len(db1.All()), ShouldEqual, expected)
+			c.So(len(db2.All()), ShouldEqual, expected)
+		})
+
+		c.Convey("reads the history while entries are replicated", FailureHalts, func(c C) {
+			done := make(chan struct{})
+			errs := make(chan error, 1)
+
+			go func() {
+				defer close(errs)
+
+				for {
+					select {
+					case <-done:
+						return
+					default:
+					}
+
+					if _, err := db2.History(ctx, "key"); err != nil {
+						errs <- err
+						return
+					}
+				}
+			}()
+
+			for n := 0; n < writesPerWriter; n++ {
+				_, err := db1.Put(ctx, "key", []byte(fmt.Sprintf("value-%d", n)))
+				c.So(err, ShouldBeNil)
+			}
+
+			for i := 0; i < 100; i++ {
+				if history, _ := db2.History(ctx, "key"); len(history) == writesPerWriter {
+					break
+				}
+
+				<-time.After(time.Millisecond * 100)
+			}
+
+			close(done)
+			c.So(<-errs, ShouldBeNil)
+
+			history, err := db2.History(ctx, "key")
+			c.So(err, ShouldBeNil)
+			c.So(len(history), ShouldEqual, writesPerWriter)
+		})
+
+		c.So(orbitdb1.Close(), ShouldBeNil)
+		c.So(orbitdb2.Close(), ShouldBeNil)
+
+		TeardownNetwork()
+	})
+}
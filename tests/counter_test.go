@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	orbitdb2 "berty.tech/go-orbit-db"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCounterStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	dbPath := "./orbitdb/tests/counter"
+
+	defer os.RemoveAll(dbPath)
+
+	Convey("orbit-db - Counter Store", t, FailureHalts, func(c C) {
+		err := os.RemoveAll(dbPath)
+		c.So(err, ShouldBeNil)
+
+		db1Path := path.Join(dbPath, "1")
+		_, db1IPFS := MakeIPFS(ctx, t)
+
+		orbitdb1, err := orbitdb2.NewOrbitDB(ctx, db1IPFS, &orbitdb2.NewOrbitDBOptions{
+			Directory: &db1Path,
+		})
+		defer orbitdb1.Close()
+
+		c.So(err, ShouldBeNil)
+
+		c.Convey("creates and opens a database", FailureHalts, func(c C) {
+			db, err := orbitdb1.Counter(ctx, "first counter database", nil)
+			c.So(err, ShouldBeNil)
+
+			c.So(db, ShouldNotBeNil)
+			c.So(db.Type(), ShouldEqual, "counter")
+			c.So(db.DBName(), ShouldEqual, "first counter database")
+			c.So(db.Value(), ShouldEqual, 0)
+		})
+
+		c.Convey("increments and decrements the counter", FailureHalts, func(c C) {
+			db, err := orbitdb1.Counter(ctx, "inc dec counter database", nil)
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Inc(ctx, 10)
+			c.So(err, ShouldBeNil)
+			c.So(db.Value(), ShouldEqual, 10)
+
+			_, err = db.Inc(ctx, 5)
+			c.So(err, ShouldBeNil)
+			c.So(db.Value(), ShouldEqual, 15)
+
+			_, err = db.Dec(ctx, 20)
+			c.So(err, ShouldBeNil)
+			c.So(db.Value(), ShouldEqual, -5)
+		})
+
+		c.Convey("restores the counter value after loading", FailureHalts, func(c C) {
+			db, err := orbitdb1.Counter(ctx, "persisted counter database", nil)
+			c.So(err, ShouldBeNil)
+
+			for i := 0; i < 5; i++ {
+				_, err = db.Inc(ctx, 2)
+				c.So(err, ShouldBeNil)
+			}
+
+			err = db.Close()
+			c.So(err, ShouldBeNil)
+
+			db, err = orbitdb1.Counter(ctx, db.Address().String(), nil)
+			c.So(err, ShouldBeNil)
+
+			err = db.Load(ctx, -1)
+			c.So(err, ShouldBeNil)
+			c.So(db.Value(), ShouldEqual, 10)
+		})
+
+		TeardownNetwork()
+	})
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	orbitdb2 "berty.tech/go-orbit-db"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDocumentStore(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	dbPath := "./orbitdb/tests/docstore"
+	dbname := "orbit-db-tests"
+
+	defer os.RemoveAll(dbPath)
+
+	Convey("orbit-db - Document Store", t, FailureHalts, func(c C) {
+		err := os.RemoveAll(dbPath)
+		c.So(err, ShouldBeNil)
+
+		db1Path := path.Join(dbPath, "1")
+		_, db1IPFS := MakeIPFS(ctx, t)
+
+		orbitdb1, err := orbitdb2.NewOrbitDB(ctx, db1IPFS, &orbitdb2.NewOrbitDBOptions{
+			Directory: &db1Path,
+		})
+		defer orbitdb1.Close()
+
+		c.So(err, ShouldBeNil)
+
+		db, err := orbitdb1.Docs(ctx, dbname, nil)
+		c.So(err, ShouldBeNil)
+
+		c.Convey("creates and opens a database", FailureHalts, func(c C) {
+			db, err := orbitdb1.Docs(ctx, "first doc database", nil)
+			c.So(err, ShouldBeNil)
+
+			c.So(db, ShouldNotBeNil)
+			c.So(db.Type(), ShouldEqual, "docstore")
+			c.So(db.DBName(), ShouldEqual, "first doc database")
+		})
+
+		c.Convey("put/get", FailureHalts, func(c C) {
+			_, err := db.Put(ctx, map[string]interface{}{"_id": "doc1", "name": "hello1"})
+			c.So(err, ShouldBeNil)
+
+			doc, err := db.Get(ctx, "doc1")
+			c.So(err, ShouldBeNil)
+			c.So(doc["name"], ShouldEqual, "hello1")
+		})
+
+		c.Convey("put updates a document", FailureHalts, func(c C) {
+			_, err := db.Put(ctx, map[string]interface{}{"_id": "doc1", "name": "hello1"})
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, map[string]interface{}{"_id": "doc1", "name": "hello2"})
+			c.So(err, ShouldBeNil)
+
+			doc, err := db.Get(ctx, "doc1")
+			c.So(err, ShouldBeNil)
+			c.So(doc["name"], ShouldEqual, "hello2")
+		})
+
+		c.Convey("put fails without a key", FailureHalts, func(c C) {
+			_, err := db.Put(ctx, map[string]interface{}{"name": "hello1"})
+			c.So(err, ShouldNotBeNil)
+		})
+
+		c.Convey("deletes a document", FailureHalts, func(c C) {
+			_, err := db.Put(ctx, map[string]interface{}{"_id": "doc1", "name": "hello1"})
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Delete(ctx, "doc1")
+			c.So(err, ShouldBeNil)
+
+			doc, err := db.Get(ctx, "doc1")
+			c.So(err, ShouldBeNil)
+			c.So(doc, ShouldBeNil)
+
+			_, err = db.Delete(ctx, "doc1")
+			c.So(err, ShouldNotBeNil)
+		})
+
+		c.Convey("queries documents", FailureHalts, func(c C) {
+			for _, doc := range []map[string]interface{}{
+				{"_id": "doc1", "views": 10},
+				{"_id": "doc2", "views": 20},
+				{"_id": "doc3", "views": 30},
+			} {
+				_, err := db.Put(ctx, doc)
+				c.So(err, ShouldBeNil)
+			}
+
+			docs, err := db.Query(ctx, func(doc map[string]interface{}) bool {
+				views, ok := doc["views"].(float64)
+				return ok && views > 15
+			})
+			c.So(err, ShouldBeNil)
+			c.So(len(docs), ShouldEqual, 2)
+			c.So(docs[0]["_id"], ShouldEqual, "doc2")
+			c.So(docs[1]["_id"], ShouldEqual, "doc3")
+		})
+
+		c.Convey("rebuilds the latest documents when loading the log", FailureHalts, func(c C) {
+			_, err := db.Put(ctx, map[string]interface{}{"_id": "doc1", "name": "hello1"})
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, map[string]interface{}{"_id": "doc2", "name": "hello2"})
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, map[string]interface{}{"_id": "doc1", "name": "hello3"})
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Delete(ctx, "doc2")
+			c.So(err, ShouldBeNil)
+
+			c.So(db.Close(), ShouldBeNil)
+
+			reopened, err := orbitdb1.Docs(ctx, db.Address().String(), nil)
+			c.So(err, ShouldBeNil)
+			defer reopened.Close()
+
+			c.So(reopened.Load(ctx, -1), ShouldBeNil)
+
+			doc, err := reopened.Get(ctx, "doc1")
+			c.So(err, ShouldBeNil)
+			c.So(doc["name"], ShouldEqual, "hello3")
+
+			doc, err = reopened.Get(ctx, "doc2")
+			c.So(err, ShouldBeNil)
+			c.So(doc, ShouldBeNil)
+		})
+
+		c.Convey("indexes documents by a custom field", FailureHalts, func(c C) {
+			indexBy := "doc_id"
+			db, err := orbitdb1.Docs(ctx, "custom index doc database", &orbitdb2.CreateDBOptions{
+				StoreSpecificOpts: &orbitdb2.DocumentStoreOptions{IndexBy: &indexBy},
+			})
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, map[string]interface{}{"doc_id": "doc1", "name": "hello1"})
+			c.So(err, ShouldBeNil)
+
+			doc, err := db.Get(ctx, "doc1")
+			c.So(err, ShouldBeNil)
+			c.So(doc["name"], ShouldEqual, "hello1")
+		})
+
+		TeardownNetwork()
+	})
+}
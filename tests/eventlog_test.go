@@ -5,17 +5,20 @@ import (
 	"context"
 	"fmt"
 	"github.com/ipfs/go-cid"
+	"io"
 	"os"
 	"path"
 	"testing"
 	"time"
 
 	orbitdb "berty.tech/go-orbit-db"
+	"berty.tech/go-orbit-db/stores/eventlogstore"
 	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/pkg/errors"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
-func cidPtr (c cid.Cid) *cid.Cid{
+func cidPtr(c cid.Cid) *cid.Cid {
 	return &c
 }
 
@@ -108,6 +111,154 @@ func TestLogDatabase(t *testing.T) {
 			}
 		})
 
+		c.Convey("follows the log", FailureHalts, func(c C) {
+			db, err := orbitdb1.Log(ctx, "follow database", nil)
+			c.So(err, ShouldBeNil)
+
+			for i := 1; i <= 3; i++ {
+				_, err := db.Add(ctx, []byte(fmt.Sprintf("hello%d", i)))
+				c.So(err, ShouldBeNil)
+			}
+
+			followCtx, followCancel := context.WithCancel(ctx)
+			two := 2
+
+			ops, err := db.Follow(followCtx, &orbitdb.StreamOptions{Amount: &two})
+			c.So(err, ShouldBeNil)
+
+			for i := 4; i <= 5; i++ {
+				_, err := db.Add(ctx, []byte(fmt.Sprintf("hello%d", i)))
+				c.So(err, ShouldBeNil)
+			}
+
+			var values []string
+			for len(values) < 4 {
+				select {
+				case op := <-ops:
+					values = append(values, string(op.GetValue()))
+				case <-time.After(time.Second):
+					c.So("missing operations", ShouldBeEmpty)
+				}
+			}
+
+			c.So(values, ShouldResemble, []string{"hello2", "hello3", "hello4", "hello5"})
+
+			followCancel()
+
+			select {
+			case _, ok := <-ops:
+				c.So(ok, ShouldBeFalse)
+			case <-time.After(time.Second):
+				c.So("chan not closed", ShouldBeEmpty)
+			}
+		})
+
+		c.Convey("closes the followers with the store", FailureHalts, func(c C) {
+			db, err := orbitdb1.Log(ctx, "follow close database", nil)
+			c.So(err, ShouldBeNil)
+
+			ops, err := db.Follow(ctx, nil)
+			c.So(err, ShouldBeNil)
+
+			c.So(db.Close(), ShouldBeNil)
+
+			select {
+			case _, ok := <-ops:
+				c.So(ok, ShouldBeFalse)
+			case <-time.After(time.Second):
+				c.So("chan not closed", ShouldBeEmpty)
+			}
+		})
+
+		c.Convey("applies the stream bounds to followed entries", FailureHalts, func(c C) {
+			db, err := orbitdb1.Log(ctx, "follow bounds database", nil)
+			c.So(err, ShouldBeNil)
+
+			first, err := db.Add(ctx, []byte("hello1"))
+			c.So(err, ShouldBeNil)
+
+			two := 2
+			gtOps, err := db.Follow(ctx, &orbitdb.StreamOptions{GT: cidPtr(first.GetEntry().GetHash()), Amount: &two})
+			c.So(err, ShouldBeNil)
+
+			ltOps, err := db.Follow(ctx, &orbitdb.StreamOptions{LTE: cidPtr(first.GetEntry().GetHash())})
+			c.So(err, ShouldBeNil)
+
+			for i := 2; i <= 4; i++ {
+				_, err := db.Add(ctx, []byte(fmt.Sprintf("hello%d", i)))
+				c.So(err, ShouldBeNil)
+			}
+
+			var values []string
+			for done := false; !done; {
+				select {
+				case op, ok := <-gtOps:
+					if !ok {
+						done = true
+						break
+					}
+
+					values = append(values, string(op.GetValue()))
+				case <-time.After(time.Second):
+					c.So("chan not closed", ShouldBeEmpty)
+				}
+			}
+
+			c.So(values, ShouldResemble, []string{"hello2", "hello3"})
+
+			select {
+			case op := <-ltOps:
+				c.So(string(op.GetValue()), ShouldEqual, "hello1")
+			case <-time.After(time.Second):
+				c.So("missing operation", ShouldBeEmpty)
+			}
+
+			select {
+			case op := <-ltOps:
+				c.So(string(op.GetValue()), ShouldBeEmpty)
+			case <-time.After(time.Millisecond * 100):
+			}
+		})
+
+		c.Convey("selects items by time range", FailureHalts, func(c C) {
+			db, err := orbitdb1.Log(ctx, "time range database", nil)
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Add(ctx, []byte("before"))
+			c.So(err, ShouldBeNil)
+
+			<-time.After(time.Millisecond * 50)
+			since := time.Now()
+
+			for i := 1; i <= 3; i++ {
+				_, err := db.Add(ctx, []byte(fmt.Sprintf("hello%d", i)))
+				c.So(err, ShouldBeNil)
+			}
+
+			until := time.Now()
+			<-time.After(time.Millisecond * 50)
+
+			_, err = db.Add(ctx, []byte("after"))
+			c.So(err, ShouldBeNil)
+
+			items, err := db.List(ctx, &orbitdb.StreamOptions{Since: &since, Until: &until, Amount: &infinity})
+			c.So(err, ShouldBeNil)
+			c.So(len(items), ShouldEqual, 3)
+
+			for i := 1; i <= 3; i++ {
+				c.So(string(items[i-1].GetValue()), ShouldEqual, fmt.Sprintf("hello%d", i))
+			}
+
+			items, err = db.List(ctx, &orbitdb.StreamOptions{Since: &since, Amount: &infinity})
+			c.So(err, ShouldBeNil)
+			c.So(len(items), ShouldEqual, 4)
+
+			items, err = db.List(ctx, &orbitdb.StreamOptions{Until: &until})
+			c.So(err, ShouldBeNil)
+			c.So(len(items), ShouldEqual, 1)
+			c.So(string(items[0].GetValue()), ShouldEqual, "hello3")
+		})
+
 		c.Convey("adds an item that is > 256 bytes", FailureHalts, func(c C) {
 			db, err := orbitdb1.Log(ctx, "third database", nil)
 			c.So(err, ShouldBeNil)
@@ -189,6 +340,192 @@ func TestLogDatabase(t *testing.T) {
 				})
 			})
 
+			c.Convey("errors", FailureHalts, func(c C) {
+				c.Convey("returns ErrNotFound for a missing entry", FailureHalts, func(c C) {
+					op, err := db.Get(ctx, ops[0].GetEntry().GetHash())
+					c.So(err, ShouldBeNil)
+					c.So(string(op.GetValue()), ShouldEqual, "hello0")
+
+					other, err := orbitdb1.Log(ctx, "other database", nil)
+					c.So(err, ShouldBeNil)
+
+					otherOp, err := other.Add(ctx, []byte("elsewhere"))
+					c.So(err, ShouldBeNil)
+
+					_, err = db.Get(ctx, otherOp.GetEntry().GetHash())
+					c.So(err, ShouldEqual, eventlogstore.ErrNotFound)
+
+					_, err = db.List(ctx, &orbitdb.StreamOptions{GT: cidPtr(otherOp.GetEntry().GetHash())})
+					c.So(errors.Cause(err), ShouldEqual, eventlogstore.ErrNotFound)
+				})
+
+				c.Convey("stops streaming when the context is done", FailureHalts, func(c C) {
+					streamCtx, streamCancel := context.WithCancel(ctx)
+					ch := make(chan operation.Operation)
+
+					done := make(chan error)
+					go func() {
+						done <- db.Stream(streamCtx, ch, &orbitdb.StreamOptions{Amount: &infinity})
+					}()
+
+					<-ch
+					streamCancel()
+
+					select {
+					case err := <-done:
+						c.So(err, ShouldEqual, context.Canceled)
+					case <-time.After(time.Second):
+						c.So("stream still blocked", ShouldBeEmpty)
+					}
+
+					_, ok := <-ch
+					c.So(ok, ShouldBeFalse)
+				})
+			})
+
+			c.Convey("cursors", FailureHalts, func(c C) {
+				c.Convey("iterates forward and backward", FailureHalts, func(c C) {
+					it, err := db.Iterator(nil)
+					c.So(err, ShouldBeNil)
+
+					for i := 0; i < itemCount; i++ {
+						op, err := it.Next(ctx)
+						c.So(err, ShouldBeNil)
+						c.So(string(op.GetValue()), ShouldEqual, fmt.Sprintf("hello%d", i))
+					}
+
+					_, err = it.Next(ctx)
+					c.So(err, ShouldEqual, io.EOF)
+
+					op, err := it.Prev(ctx)
+					c.So(err, ShouldBeNil)
+					c.So(string(op.GetValue()), ShouldEqual, fmt.Sprintf("hello%d", itemCount-1))
+
+					op, err = it.Prev(ctx)
+					c.So(err, ShouldBeNil)
+					c.So(string(op.GetValue()), ShouldEqual, fmt.Sprintf("hello%d", itemCount-2))
+				})
+
+				c.Convey("switches direction at both ends", FailureHalts, func(c C) {
+					for _, reverse := range []bool{false, true} {
+						it, err := db.Iterator(&orbitdb.EventLogIteratorOptions{Reverse: reverse})
+						c.So(err, ShouldBeNil)
+
+						first, last := "hello0", fmt.Sprintf("hello%d", itemCount-1)
+						if reverse {
+							first, last = last, first
+						}
+
+						for i := 0; i < itemCount; i++ {
+							_, err := it.Next(ctx)
+							c.So(err, ShouldBeNil)
+						}
+
+						_, err = it.Next(ctx)
+						c.So(err, ShouldEqual, io.EOF)
+
+						op, err := it.Prev(ctx)
+						c.So(err, ShouldBeNil)
+						c.So(string(op.GetValue()), ShouldEqual, last)
+
+						for i := 0; i < itemCount-1; i++ {
+							_, err := it.Prev(ctx)
+							c.So(err, ShouldBeNil)
+						}
+
+						_, err = it.Prev(ctx)
+						c.So(err, ShouldEqual, io.EOF)
+
+						op, err = it.Next(ctx)
+						c.So(err, ShouldBeNil)
+						c.So(string(op.GetValue()), ShouldEqual, first)
+					}
+				})
+
+				c.Convey("returns the entries added after reaching the end", FailureHalts, func(c C) {
+					it, err := db.Iterator(nil)
+					c.So(err, ShouldBeNil)
+
+					for i := 0; i < itemCount; i++ {
+						_, err := it.Next(ctx)
+						c.So(err, ShouldBeNil)
+					}
+
+					_, err = it.Next(ctx)
+					c.So(err, ShouldEqual, io.EOF)
+
+					_, err = db.Add(ctx, []byte("late"))
+					c.So(err, ShouldBeNil)
+
+					op, err := it.Next(ctx)
+					c.So(err, ShouldBeNil)
+					c.So(string(op.GetValue()), ShouldEqual, "late")
+				})
+
+				c.Convey("iterates in reverse", FailureHalts, func(c C) {
+					it, err := db.Iterator(&orbitdb.EventLogIteratorOptions{Reverse: true})
+					c.So(err, ShouldBeNil)
+
+					op, err := it.Next(ctx)
+					c.So(err, ShouldBeNil)
+					c.So(string(op.GetValue()), ShouldEqual, fmt.Sprintf("hello%d", itemCount-1))
+
+					_, err = it.Prev(ctx)
+					c.So(err, ShouldEqual, io.EOF)
+				})
+
+				c.Convey("resumes from a cursor", FailureHalts, func(c C) {
+					it, err := db.Iterator(nil)
+					c.So(err, ShouldBeNil)
+
+					_, err = it.Next(ctx)
+					c.So(err, ShouldBeNil)
+
+					_, err = it.Next(ctx)
+					c.So(err, ShouldBeNil)
+
+					cursor := it.Cursor()
+
+					it, err = db.Iterator(&orbitdb.EventLogIteratorOptions{Cursor: &cursor})
+					c.So(err, ShouldBeNil)
+
+					op, err := it.Next(ctx)
+					c.So(err, ShouldBeNil)
+					c.So(string(op.GetValue()), ShouldEqual, "hello2")
+				})
+
+				c.Convey("seeks across interleaved writes", FailureHalts, func(c C) {
+					it, err := db.Iterator(nil)
+					c.So(err, ShouldBeNil)
+
+					for i := 0; i < itemCount; i++ {
+						op, err := it.Next(ctx)
+						c.So(err, ShouldBeNil)
+						c.So(string(op.GetValue()), ShouldEqual, fmt.Sprintf("hello%d", i))
+
+						_, err = db.Add(ctx, []byte(fmt.Sprintf("interleaved%d", i)))
+						c.So(err, ShouldBeNil)
+					}
+
+					for i := 0; i < itemCount; i++ {
+						op, err := it.Next(ctx)
+						c.So(err, ShouldBeNil)
+						c.So(string(op.GetValue()), ShouldEqual, fmt.Sprintf("interleaved%d", i))
+					}
+
+					op, err := db.Get(ctx, ops[itemCount-1].GetEntry().GetHash())
+					c.So(err, ShouldBeNil)
+					c.So(string(op.GetValue()), ShouldEqual, fmt.Sprintf("hello%d", itemCount-1))
+				})
+
+				c.Convey("fails on unknown cursors", FailureHalts, func(c C) {
+					cursor := orbitdb.EventLogCursor("unknown")
+
+					_, err := db.Iterator(&orbitdb.EventLogIteratorOptions{Cursor: &cursor})
+					c.So(err, ShouldEqual, eventlogstore.ErrUnknownCursor)
+				})
+			})
+
 			c.Convey("collect", FailureHalts, func(c C) {
 				c.Convey("returns all items", FailureHalts, func(c C) {
 					messages, err := db.List(ctx, &orbitdb.StreamOptions{Amount: &infinity})
@@ -0,0 +1,182 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"berty.tech/go-orbit-db/events"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testEvent struct {
+	n int
+}
+
+type otherTestEvent struct{}
+
+func TestEventEmitter(t *testing.T) {
+	Convey("events - EventEmitter", t, FailureHalts, func(c C) {
+		const eventCount = 200
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		emitter := &events.EventEmitter{}
+
+		c.Convey("delivers all the events in order to a slow queued subscriber", FailureHalts, func(c C) {
+			lock := sync.Mutex{}
+			received := []int{}
+			done := make(chan struct{})
+
+			emitter.Subscribe(ctx, func(evt events.Event) {
+				<-time.After(time.Microsecond * 100)
+
+				lock.Lock()
+				defer lock.Unlock()
+
+				received = append(received, evt.(*testEvent).n)
+				if len(received) == eventCount {
+					close(done)
+				}
+			})
+
+			for i := 0; i < eventCount; i++ {
+				emitter.Emit(&testEvent{n: i})
+			}
+
+			select {
+			case <-done:
+			case <-time.After(time.Second * 10):
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			c.So(len(received), ShouldEqual, eventCount)
+			for i := range received {
+				c.So(received[i], ShouldEqual, i)
+			}
+		})
+
+		c.Convey("blocks the emitter until a blocking subscriber has room", FailureHalts, func(c C) {
+			release := make(chan struct{})
+
+			emitter.SubscribeWithOptions(ctx, func(evt events.Event) {
+				<-release
+			}, &events.SubscribeOptions{Mode: events.DeliveryModeBlock, BufferSize: 1})
+
+			emitted := make(chan struct{})
+			go func() {
+				for i := 0; i < 3; i++ {
+					emitter.Emit(&testEvent{n: i})
+				}
+				close(emitted)
+			}()
+
+			blocked := true
+			select {
+			case <-emitted:
+				blocked = false
+			case <-time.After(time.Millisecond * 200):
+			}
+
+			c.So(blocked, ShouldBeTrue)
+
+			close(release)
+
+			released := true
+			select {
+			case <-emitted:
+			case <-time.After(time.Second * 5):
+				released = false
+			}
+
+			c.So(released, ShouldBeTrue)
+		})
+
+		c.Convey("counts the events dropped by a dropping subscriber", FailureHalts, func(c C) {
+			release := make(chan struct{})
+			defer close(release)
+
+			sub := emitter.SubscribeWithOptions(ctx, func(evt events.Event) {
+				<-release
+			}, &events.SubscribeOptions{Mode: events.DeliveryModeDrop, BufferSize: 10})
+
+			for i := 0; i < eventCount; i++ {
+				emitter.Emit(&testEvent{n: i})
+			}
+
+			// the handler may have taken one event from the buffer
+			c.So(sub.Dropped(), ShouldBeBetweenOrEqual, eventCount-11, eventCount-10)
+		})
+
+		c.Convey("only delivers the events matching the filter", FailureHalts, func(c C) {
+			received := make(chan events.Event, eventCount)
+
+			emitter.SubscribeWithOptions(ctx, func(evt events.Event) {
+				received <- evt
+			}, &events.SubscribeOptions{Filter: func(evt events.Event) bool {
+				_, ok := evt.(*testEvent)
+				return ok
+			}})
+
+			emitter.Emit(&otherTestEvent{})
+			emitter.Emit(&testEvent{n: 1})
+
+			var evt events.Event
+			select {
+			case evt = <-received:
+			case <-time.After(time.Second * 5):
+			}
+
+			c.So(evt, ShouldHaveSameTypeAs, &testEvent{})
+		})
+
+		c.Convey("sends the events of a type on a typed channel", FailureHalts, func(c C) {
+			subCtx, subCancel := context.WithCancel(ctx)
+
+			ch := make(chan *testEvent)
+			events.SubscribeChan(subCtx, emitter, ch)
+
+			emitter.Emit(&otherTestEvent{})
+			emitter.Emit(&testEvent{n: 1})
+			emitter.Emit(&testEvent{n: 2})
+
+			c.So((<-ch).n, ShouldEqual, 1)
+			c.So((<-ch).n, ShouldEqual, 2)
+
+			subCancel()
+
+			_, ok := <-ch
+			c.So(ok, ShouldBeFalse)
+
+			c.So(func() { events.SubscribeChan(ctx, emitter, &testEvent{}) }, ShouldPanic)
+		})
+
+		c.Convey("stops delivering events once unsubscribed", FailureHalts, func(c C) {
+			received := make(chan events.Event, eventCount)
+
+			sub := emitter.Subscribe(ctx, func(evt events.Event) {
+				received <- evt
+			})
+
+			emitter.Emit(&testEvent{n: 1})
+			<-received
+
+			sub.Unsubscribe()
+			emitter.Emit(&testEvent{n: 2})
+
+			var evt events.Event
+			select {
+			case evt = <-received:
+			case <-time.After(time.Millisecond * 200):
+			}
+
+			c.So(evt, ShouldBeNil)
+
+			c.So(len(emitter.Subscribers), ShouldEqual, 0)
+		})
+	})
+}
@@ -0,0 +1,165 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	ipfslog "berty.tech/go-ipfs-log"
+	orbitdb "berty.tech/go-orbit-db"
+	"berty.tech/go-orbit-db/accesscontroller"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/ipfs/go-cid"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStoreHooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	defer cancel()
+
+	dbPath := "./orbitdb/tests/hooks"
+
+	_, ipfs := MakeIPFS(ctx, t)
+
+	defer os.RemoveAll(dbPath)
+
+	Convey("orbit-db - Store hooks", t, FailureHalts, func(c C) {
+		err := os.RemoveAll(dbPath)
+		c.So(err, ShouldBeNil)
+
+		dbPath1 := path.Join(dbPath, "1")
+		dbPath2 := path.Join(dbPath, "2")
+
+		orbitdb1, err := orbitdb.NewOrbitDB(ctx, ipfs, &orbitdb.NewOrbitDBOptions{Directory: &dbPath1})
+		c.So(err, ShouldBeNil)
+		defer orbitdb1.Close()
+
+		orbitdb2, err := orbitdb.NewOrbitDB(ctx, ipfs, &orbitdb.NewOrbitDBOptions{Directory: &dbPath2})
+		c.So(err, ShouldBeNil)
+		defer orbitdb2.Close()
+
+		maxSize := func(_ context.Context, op operation.Operation) error {
+			if len(op.GetValue()) > 8 {
+				return errors.New("value too large")
+			}
+
+			return nil
+		}
+
+		c.Convey("rejects local writes refused by a validate hook", FailureHalts, func(c C) {
+			db, err := orbitdb1.KeyValue(ctx, "hooks-validate", &orbitdb.CreateDBOptions{
+				ValidateHooks: []orbitdb.ValidateHook{maxSize},
+			})
+			c.So(err, ShouldBeNil)
+			defer db.Close()
+
+			_, err = db.Put(ctx, "key", []byte("small"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "key", []byte("way too large"))
+			c.So(err, ShouldNotBeNil)
+
+			value, err := db.Get(ctx, "key")
+			c.So(err, ShouldBeNil)
+			c.So(string(value), ShouldEqual, "small")
+			c.So(db.OpLog().Values().Len(), ShouldEqual, 1)
+		})
+
+		c.Convey("transforms operations with a pre-write hook", FailureHalts, func(c C) {
+			db, err := orbitdb1.KeyValue(ctx, "hooks-pre-write", &orbitdb.CreateDBOptions{})
+			c.So(err, ShouldBeNil)
+			defer db.Close()
+
+			db.AddPreWriteHook(func(_ context.Context, op operation.Operation) (operation.Operation, error) {
+				return operation.NewOperation(op.GetKey(), op.GetOperation(), bytes.ToUpper(op.GetValue())), nil
+			})
+
+			_, err = db.Put(ctx, "key", []byte("hello"))
+			c.So(err, ShouldBeNil)
+
+			value, err := db.Get(ctx, "key")
+			c.So(err, ShouldBeNil)
+			c.So(string(value), ShouldEqual, "HELLO")
+		})
+
+		c.Convey("calls post-write hooks once the index is updated", FailureHalts, func(c C) {
+			db, err := orbitdb1.KeyValue(ctx, "hooks-post-write", &orbitdb.CreateDBOptions{})
+			c.So(err, ShouldBeNil)
+			defer db.Close()
+
+			var (
+				written ipfslog.Entry
+				indexed []byte
+				root    cid.Cid
+			)
+
+			db.AddPostWriteHook(func(ctx context.Context, addr cid.Cid, e ipfslog.Entry, heads []cid.Cid) error {
+				written, root = e, addr
+				indexed, _ = db.Get(ctx, "key")
+
+				return nil
+			})
+
+			e, err := db.Put(ctx, "key", []byte("hello"))
+			c.So(err, ShouldBeNil)
+			c.So(written, ShouldNotBeNil)
+			c.So(written.GetHash().String(), ShouldEqual, e.GetEntry().GetHash().String())
+			c.So(root.String(), ShouldEqual, db.Address().GetRoot().String())
+			c.So(string(indexed), ShouldEqual, "hello")
+		})
+
+		c.Convey("discards replicated entries refused by a validate hook", FailureHalts, func(c C) {
+			ac := &accesscontroller.CreateAccessControllerOptions{
+				Access: map[string][]string{
+					"write": {
+						orbitdb1.Identity().ID,
+						orbitdb2.Identity().ID,
+					},
+				},
+			}
+
+			replicate := false
+
+			db1, err := orbitdb1.KeyValue(ctx, "hooks-replicated", &orbitdb.CreateDBOptions{
+				AccessController: ac,
+				Replicate:        &replicate,
+			})
+			c.So(err, ShouldBeNil)
+			defer db1.Close()
+
+			db2, err := orbitdb2.KeyValue(ctx, db1.Address().String(), &orbitdb.CreateDBOptions{
+				AccessController: ac,
+				Replicate:        &replicate,
+				ValidateHooks:    []orbitdb.ValidateHook{maxSize},
+			})
+			c.So(err, ShouldBeNil)
+			defer db2.Close()
+
+			_, err = db1.Put(ctx, "key", []byte("way too large"))
+			c.So(err, ShouldBeNil)
+
+			err = db2.Sync(ctx, db1.OpLog().Heads().Slice())
+			c.So(err, ShouldBeNil)
+
+			_, err = db1.Put(ctx, "other", []byte("small"))
+			c.So(err, ShouldBeNil)
+
+			err = db2.Sync(ctx, db1.OpLog().Heads().Slice())
+			c.So(err, ShouldBeNil)
+
+			<-time.After(time.Millisecond * 300)
+
+			value, err := db2.Get(ctx, "key")
+			c.So(err, ShouldBeNil)
+			c.So(value, ShouldBeNil)
+
+			value, err = db2.Get(ctx, "other")
+			c.So(err, ShouldBeNil)
+			c.So(string(value), ShouldEqual, "small")
+		})
+	})
+}
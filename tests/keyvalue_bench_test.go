@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	orbitdb "berty.tech/go-orbit-db"
+)
+
+// benchmarkKeyValuePut Measures the time needed to put a value in a store
+// whose oplog already contains `size` entries, the store is filled once and
+// shared by the runs of the benchmark
+func benchmarkKeyValuePut(b *testing.B, size int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dbPath, err := ioutil.TempDir("", "orbitdb-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dbPath)
+
+	_, ipfs := MakeIPFS(ctx, b)
+	defer TeardownNetwork()
+
+	odb, err := orbitdb.NewOrbitDB(ctx, ipfs, &orbitdb.NewOrbitDBOptions{Directory: &dbPath})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer odb.Close()
+
+	replicate := false
+
+	db, err := odb.KeyValue(ctx, fmt.Sprintf("bench-%d", size), &orbitdb.CreateDBOptions{Replicate: &replicate})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 0; i < size; i++ {
+		if _, err := db.Put(ctx, fmt.Sprintf("key%d", i%1000), []byte(fmt.Sprintf("value%d", i))); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run(fmt.Sprintf("%d entries", size), func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := db.Put(ctx, fmt.Sprintf("key%d", i%1000), []byte(fmt.Sprintf("value%d", i))); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkKeyValuePut(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000} {
+		benchmarkKeyValuePut(b, size)
+	}
+}
@@ -2,12 +2,16 @@ package tests
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path"
 	"testing"
 	"time"
 
 	orbitdb2 "berty.tech/go-orbit-db"
+	"berty.tech/go-orbit-db/stores"
+	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/ipfs/go-cid"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -55,6 +59,28 @@ func TestKeyValueStore(t *testing.T) {
 			c.So(string(value), ShouldEqual, "hello1")
 		})
 
+		c.Convey("sends the write events on a typed channel", FailureHalts, func(c C) {
+			subCtx, subCancel := context.WithCancel(ctx)
+			writes := stores.SubscribeWrite(subCtx, db)
+
+			op, err := db.Put(ctx, "key1", []byte("hello1"))
+			c.So(err, ShouldBeNil)
+
+			var evt *stores.EventWrite
+			select {
+			case evt = <-writes:
+			case <-time.After(time.Second * 5):
+			}
+
+			c.So(evt, ShouldNotBeNil)
+			c.So(evt.Entry.GetHash().String(), ShouldEqual, op.GetEntry().GetHash().String())
+
+			subCancel()
+
+			_, ok := <-writes
+			c.So(ok, ShouldBeFalse)
+		})
+
 		c.Convey("get", FailureHalts, func(c C) {
 			_, err := db.Put(ctx, "key1", []byte("hello2"))
 			c.So(err, ShouldBeNil)
@@ -130,6 +156,319 @@ func TestKeyValueStore(t *testing.T) {
 			c.So(value, ShouldEqual, nil)
 		})
 
+		c.Convey("writes several keys in a single batch", FailureHalts, func(c C) {
+			_, err := db.Put(ctx, "key1", []byte("hello1"))
+			c.So(err, ShouldBeNil)
+
+			key1, key2, key3 := "key1", "key2", "key3"
+
+			op, err := db.Batch(ctx, []operation.Operation{
+				operation.NewOperation(&key2, "PUT", []byte("hello2")),
+				operation.NewOperation(&key1, "DEL", nil),
+				operation.NewOperation(&key3, "PUT", []byte("hello3")),
+				operation.NewOperation(&key3, "PUT", []byte("hello3bis")),
+			})
+			c.So(err, ShouldBeNil)
+			c.So(op.GetOperation(), ShouldEqual, "BATCH")
+			c.So(len(op.GetOperations()), ShouldEqual, 4)
+			c.So(db.OpLog().Values().Len(), ShouldEqual, 2)
+
+			all := db.All()
+			c.So(len(all), ShouldEqual, 2)
+			c.So(string(all["key2"]), ShouldEqual, "hello2")
+			c.So(string(all["key3"]), ShouldEqual, "hello3bis")
+
+			_, err = db.Batch(ctx, []operation.Operation{
+				operation.NewOperation(&key1, "PUT", []byte("hello1")),
+				operation.NewOperation(nil, "PUT", []byte("no key")),
+			})
+			c.So(err, ShouldNotBeNil)
+		})
+
+		c.Convey("hides keys once their ttl has passed", FailureHalts, func(c C) {
+			_, err := db.PutWithTTL(ctx, "session", []byte("active"), time.Millisecond*200)
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "profile", []byte("hello"))
+			c.So(err, ShouldBeNil)
+
+			value, err := db.Get(ctx, "session")
+			c.So(err, ShouldBeNil)
+			c.So(string(value), ShouldEqual, "active")
+
+			<-time.After(time.Millisecond * 300)
+
+			value, err = db.Get(ctx, "session")
+			c.So(err, ShouldBeNil)
+			c.So(value, ShouldBeNil)
+			c.So(db.All(), ShouldNotContainKey, "session")
+			c.So(len(db.Prefix("")), ShouldEqual, 1)
+		})
+
+		c.Convey("deletes expired keys with the reaper", FailureHalts, func(c C) {
+			interval := time.Millisecond * 50
+
+			db, err := orbitdb1.KeyValue(ctx, "reaper kv database", &orbitdb2.CreateDBOptions{
+				StoreSpecificOpts: &orbitdb2.KeyValueStoreOptions{ReaperInterval: &interval},
+			})
+			c.So(err, ShouldBeNil)
+			defer db.Close()
+
+			_, err = db.PutWithTTL(ctx, "session", []byte("active"), time.Millisecond*100)
+			c.So(err, ShouldBeNil)
+
+			<-time.After(time.Millisecond * 400)
+
+			c.So(db.OpLog().Values().Len(), ShouldEqual, 2)
+
+			ops := db.OpLog().Values().Slice()
+			op, err := operation.ParseOperation(ops[len(ops)-1])
+			c.So(err, ShouldBeNil)
+			c.So(op.GetOperation(), ShouldEqual, "BATCH")
+			c.So(*op.GetOperations()[0].GetKey(), ShouldEqual, "session")
+		})
+
+		c.Convey("notifies the changes of watched keys", FailureHalts, func(c C) {
+			watchCtx, cancel := context.WithCancel(ctx)
+			changes := db.Watch(watchCtx, "user/")
+
+			_, err := db.Put(ctx, "user/1", []byte("hello1"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "group/1", []byte("ignored"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "user/1", []byte("hello2"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Delete(ctx, "user/1")
+			c.So(err, ShouldBeNil)
+
+			var received []orbitdb2.KeyChange
+			for i := 0; i < 3; i++ {
+				select {
+				case change := <-changes:
+					received = append(received, change)
+				case <-time.After(time.Second):
+				}
+			}
+
+			c.So(len(received), ShouldEqual, 3)
+			c.So(received[0].Key, ShouldEqual, "user/1")
+			c.So(received[0].OldValue, ShouldBeNil)
+			c.So(string(received[0].NewValue), ShouldEqual, "hello1")
+			c.So(string(received[1].OldValue), ShouldEqual, "hello1")
+			c.So(string(received[1].NewValue), ShouldEqual, "hello2")
+			c.So(received[2].Operation.GetOperation(), ShouldEqual, "DEL")
+			c.So(string(received[2].OldValue), ShouldEqual, "hello2")
+			c.So(received[2].NewValue, ShouldBeNil)
+			c.So(received[2].Entry, ShouldNotBeNil)
+
+			cancel()
+
+			select {
+			case _, ok := <-changes:
+				c.So(ok, ShouldBeFalse)
+			case <-time.After(time.Second):
+				c.So("channel not closed", ShouldBeEmpty)
+			}
+		})
+
+		c.Convey("returns the history of a key", FailureHalts, func(c C) {
+			first, err := db.Put(ctx, "key1", []byte("hello1"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "key2", []byte("other"))
+			c.So(err, ShouldBeNil)
+
+			second, err := db.Put(ctx, "key1", []byte("hello2"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Delete(ctx, "key1")
+			c.So(err, ShouldBeNil)
+
+			history, err := db.History(ctx, "key1")
+			c.So(err, ShouldBeNil)
+			c.So(len(history), ShouldEqual, 3)
+			c.So(history[0].GetOperation(), ShouldEqual, "PUT")
+			c.So(string(history[0].GetValue()), ShouldEqual, "hello1")
+			c.So(history[1].GetOperation(), ShouldEqual, "PUT")
+			c.So(history[2].GetOperation(), ShouldEqual, "DEL")
+			c.So(history[0].GetEntry().GetIdentity().ID, ShouldEqual, orbitdb1.Identity().ID)
+			c.So(history[0].GetEntry().GetClock().GetTime(), ShouldBeLessThan, history[2].GetEntry().GetClock().GetTime())
+
+			c.Convey("reads a key at past heads", FailureHalts, func(c C) {
+				value, err := db.GetAt(ctx, "key1", []cid.Cid{first.GetEntry().GetHash()})
+				c.So(err, ShouldBeNil)
+				c.So(string(value), ShouldEqual, "hello1")
+
+				value, err = db.GetAt(ctx, "key1", []cid.Cid{second.GetEntry().GetHash()})
+				c.So(err, ShouldBeNil)
+				c.So(string(value), ShouldEqual, "hello2")
+
+				value, err = db.Get(ctx, "key1")
+				c.So(err, ShouldBeNil)
+				c.So(value, ShouldBeNil)
+			})
+		})
+
+		c.Convey("queues the changes of slow watchers", FailureHalts, func(c C) {
+			const writes = 120
+
+			watchCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+
+			changes := db.Watch(watchCtx, "slow/")
+
+			for n := 0; n < writes; n++ {
+				_, err := db.Put(ctx, "slow/key", []byte(fmt.Sprintf("%d", n)))
+				c.So(err, ShouldBeNil)
+			}
+
+			for n := 0; n < writes; n++ {
+				select {
+				case change := <-changes:
+					c.So(string(change.NewValue), ShouldEqual, fmt.Sprintf("%d", n))
+				case <-time.After(time.Second):
+					c.So(n, ShouldEqual, writes)
+					return
+				}
+			}
+		})
+
+		c.Convey("opens a read-only view at past heads", FailureHalts, func(c C) {
+			changes := db.Watch(ctx, "")
+
+			first, err := db.Put(ctx, "key1", []byte("hello1"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "key2", []byte("hello2"))
+			c.So(err, ShouldBeNil)
+
+			<-changes
+			<-changes
+
+			view, err := db.At(ctx, []cid.Cid{first.GetEntry().GetHash()})
+			c.So(err, ShouldBeNil)
+			c.So(view.Address().String(), ShouldEqual, db.Address().String())
+			c.So(view.OpLog().Values().Len(), ShouldEqual, 1)
+			c.So(string(view.Index().Get("key1").([]byte)), ShouldEqual, "hello1")
+			c.So(view.Index().Get("key2"), ShouldBeNil)
+
+			_, err = view.AddOperation(ctx, operation.NewOperation(nil, "PUT", nil), nil)
+			c.So(err, ShouldNotBeNil)
+
+			select {
+			case <-changes:
+				c.So("view notified watchers", ShouldBeEmpty)
+			case <-time.After(time.Millisecond * 100):
+			}
+		})
+
+		c.Convey("diffs two states of the store", FailureHalts, func(c C) {
+			_, err := db.Put(ctx, "removed", []byte("old"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "changed", []byte("old"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "unchanged", []byte("same"))
+			c.So(err, ShouldBeNil)
+
+			var from []cid.Cid
+			for _, h := range db.OpLog().Heads().Slice() {
+				from = append(from, h.GetHash())
+			}
+
+			_, err = db.Delete(ctx, "removed")
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "changed", []byte("new"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db.Put(ctx, "added", []byte("new"))
+			c.So(err, ShouldBeNil)
+
+			var to []cid.Cid
+			for _, h := range db.OpLog().Heads().Slice() {
+				to = append(to, h.GetHash())
+			}
+
+			entries, err := db.Diff(ctx, from, to)
+			c.So(err, ShouldBeNil)
+			c.So(len(entries), ShouldEqual, 3)
+
+			entries, err = db.Diff(ctx, nil, to)
+			c.So(err, ShouldBeNil)
+			c.So(len(entries), ShouldEqual, 6)
+
+			diff, err := db.DiffKeys(ctx, from, to)
+			c.So(err, ShouldBeNil)
+			c.So(len(diff.Added), ShouldEqual, 1)
+			c.So(string(diff.Added["added"]), ShouldEqual, "new")
+			c.So(len(diff.Changed), ShouldEqual, 1)
+			c.So(string(diff.Changed["changed"]), ShouldEqual, "new")
+			c.So(len(diff.Removed), ShouldEqual, 1)
+			c.So(string(diff.Removed["removed"]), ShouldEqual, "old")
+		})
+
+		c.Convey("returns a copy of all the keys", FailureHalts, func(c C) {
+			_, err := db.Put(ctx, "key1", []byte("hello1"))
+			c.So(err, ShouldBeNil)
+
+			all := db.All()
+			delete(all, "key1")
+
+			value, err := db.Get(ctx, "key1")
+			c.So(err, ShouldBeNil)
+			c.So(string(value), ShouldEqual, "hello1")
+			c.So(db.All(), ShouldContainKey, "key1")
+		})
+
+		c.Convey("queries keys by prefix and range", FailureHalts, func(c C) {
+			for _, key := range []string{"user/2/profile", "user/1/profile", "user/1/avatar", "group/1", "user/3/profile"} {
+				_, err := db.Put(ctx, key, []byte(key))
+				c.So(err, ShouldBeNil)
+			}
+
+			_, err := db.Delete(ctx, "user/3/profile")
+			c.So(err, ShouldBeNil)
+
+			prefixed := db.Prefix("user/1/")
+			c.So(len(prefixed), ShouldEqual, 2)
+			c.So(string(prefixed["user/1/avatar"]), ShouldEqual, "user/1/avatar")
+			c.So(prefixed, ShouldContainKey, "user/1/profile")
+
+			ranged := db.Range("user/", "user/2/")
+			c.So(len(ranged), ShouldEqual, 2)
+			c.So(ranged, ShouldNotContainKey, "group/1")
+			c.So(ranged, ShouldNotContainKey, "user/2/profile")
+
+			c.So(len(db.Prefix("user/")), ShouldEqual, 3)
+			c.So(len(db.Range("", "")), ShouldEqual, 4)
+
+			c.Convey("iterates over sorted keys with an offset and a limit", FailureHalts, func(c C) {
+				var keys []string
+
+				it := db.Iterator(&orbitdb2.KeyValueIteratorOptions{Prefix: "user/", Offset: 1, Limit: 1})
+				for it.Next() {
+					keys = append(keys, it.Key())
+					c.So(string(it.Value()), ShouldEqual, it.Key())
+				}
+
+				c.So(keys, ShouldResemble, []string{"user/1/profile"})
+
+				keys = nil
+
+				it = db.Iterator(nil)
+				for it.Next() {
+					keys = append(keys, it.Key())
+				}
+
+				c.So(keys, ShouldResemble, []string{"group/1", "user/1/avatar", "user/1/profile", "user/2/profile"})
+			})
+		})
+
 		TeardownNetwork()
 	})
 }
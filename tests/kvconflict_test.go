@@ -0,0 +1,326 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	orbitdb "berty.tech/go-orbit-db"
+	"berty.tech/go-orbit-db/accesscontroller"
+	"berty.tech/go-orbit-db/events"
+	"berty.tech/go-orbit-db/stores"
+	"berty.tech/go-orbit-db/stores/conflict"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestKeyValueConflicts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	defer cancel()
+
+	dbPath := "./orbitdb/tests/kv-conflicts"
+
+	_, ipfs := MakeIPFS(ctx, t)
+
+	defer os.RemoveAll(dbPath)
+
+	Convey("orbit-db - Key-Value conflicts", t, FailureHalts, func(c C) {
+		err := os.RemoveAll(dbPath)
+		c.So(err, ShouldBeNil)
+
+		dbPath1 := path.Join(dbPath, "1")
+		dbPath2 := path.Join(dbPath, "2")
+
+		orbitdb1, err := orbitdb.NewOrbitDB(ctx, ipfs, &orbitdb.NewOrbitDBOptions{Directory: &dbPath1})
+		c.So(err, ShouldBeNil)
+		defer orbitdb1.Close()
+
+		orbitdb2, err := orbitdb.NewOrbitDB(ctx, ipfs, &orbitdb.NewOrbitDBOptions{Directory: &dbPath2})
+		c.So(err, ShouldBeNil)
+		defer orbitdb2.Close()
+
+		ac := &accesscontroller.CreateAccessControllerOptions{
+			Access: map[string][]string{
+				"write": {
+					orbitdb1.Identity().ID,
+					orbitdb2.Identity().ID,
+				},
+			},
+		}
+
+		multiValue := true
+		replicate := false
+
+		db1, err := orbitdb1.KeyValue(ctx, "conflicts", &orbitdb.CreateDBOptions{
+			AccessController:  ac,
+			Replicate:         &replicate,
+			StoreSpecificOpts: &orbitdb.KeyValueStoreOptions{MultiValue: &multiValue},
+		})
+		c.So(err, ShouldBeNil)
+		defer db1.Close()
+
+		db2, err := orbitdb2.KeyValue(ctx, db1.Address().String(), &orbitdb.CreateDBOptions{
+			AccessController:  ac,
+			Replicate:         &replicate,
+			StoreSpecificOpts: &orbitdb.KeyValueStoreOptions{MultiValue: &multiValue},
+		})
+		c.So(err, ShouldBeNil)
+		defer db2.Close()
+
+		c.Convey("keeps sequential writes as a single value", FailureHalts, func(c C) {
+			_, err := db1.Put(ctx, "key", []byte("hello1"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db1.Put(ctx, "key", []byte("hello2"))
+			c.So(err, ShouldBeNil)
+
+			values, err := db1.GetAll(ctx, "key")
+			c.So(err, ShouldBeNil)
+			c.So(len(values), ShouldEqual, 1)
+			c.So(string(values[0]), ShouldEqual, "hello2")
+		})
+
+		c.Convey("surfaces and resolves concurrent writes", FailureHalts, func(c C) {
+			conflicts := make(chan *stores.EventConflict, 1)
+
+			subCtx, subCancel := context.WithCancel(ctx)
+			defer subCancel()
+
+			db1.Subscribe(subCtx, func(evt events.Event) {
+				if e, ok := evt.(*stores.EventConflict); ok {
+					conflicts <- e
+				}
+			})
+
+			<-time.After(time.Millisecond * 100)
+
+			_, err := db1.Put(ctx, "key", []byte("from db1"))
+			c.So(err, ShouldBeNil)
+
+			_, err = db2.Put(ctx, "key", []byte("from db2"))
+			c.So(err, ShouldBeNil)
+
+			err = db1.Sync(ctx, db2.OpLog().Heads().Slice())
+			c.So(err, ShouldBeNil)
+
+			select {
+			case e := <-conflicts:
+				c.So(e.Key, ShouldEqual, "key")
+				c.So(len(e.Values), ShouldEqual, 2)
+			case <-time.After(time.Second * 2):
+				c.So("no conflict event received", ShouldBeEmpty)
+			}
+
+			values, err := db1.GetAll(ctx, "key")
+			c.So(err, ShouldBeNil)
+			c.So(len(values), ShouldEqual, 2)
+
+			found := []string{string(values[0]), string(values[1])}
+			sort.Strings(found)
+			c.So(found, ShouldResemble, []string{"from db1", "from db2"})
+
+			_, err = db1.Resolve(ctx, "key", []byte("merged"))
+			c.So(err, ShouldBeNil)
+
+			values, err = db1.GetAll(ctx, "key")
+			c.So(err, ShouldBeNil)
+			c.So(len(values), ShouldEqual, 1)
+			c.So(string(values[0]), ShouldEqual, "merged")
+		})
+
+		c.Convey("lets blocking conflict subscribers read the store", FailureHalts, func(c C) {
+			const keys = 5
+
+			conflicts := make(chan string, keys)
+
+			subCtx, subCancel := context.WithCancel(ctx)
+			defer subCancel()
+
+			db1.SubscribeWithOptions(subCtx, func(evt events.Event) {
+				e := evt.(*stores.EventConflict)
+
+				// reads the index and the oplog while other conflicts are
+				// being emitted
+				_, _ = db1.GetAll(ctx, e.Key)
+				_, _ = db1.History(ctx, e.Key)
+				<-time.After(time.Millisecond * 20)
+
+				select {
+				case conflicts <- e.Key:
+				case <-subCtx.Done():
+				}
+			}, &events.SubscribeOptions{
+				Mode:       events.DeliveryModeBlock,
+				BufferSize: 1,
+				Filter: func(evt events.Event) bool {
+					_, ok := evt.(*stores.EventConflict)
+					return ok
+				},
+			})
+
+			for n := 0; n < keys; n++ {
+				key := fmt.Sprintf("key-%d", n)
+
+				_, err := db1.Put(ctx, key, []byte("from db1"))
+				c.So(err, ShouldBeNil)
+
+				_, err = db2.Put(ctx, key, []byte("from db2"))
+				c.So(err, ShouldBeNil)
+			}
+
+			err := db1.Sync(ctx, db2.OpLog().Heads().Slice())
+			c.So(err, ShouldBeNil)
+
+			received := map[string]struct{}{}
+			for len(received) < keys {
+				select {
+				case key := <-conflicts:
+					received[key] = struct{}{}
+				case <-time.After(time.Second * 5):
+					c.So(len(received), ShouldEqual, keys)
+					return
+				}
+			}
+
+			values, err := db1.GetAll(ctx, "key-0")
+			c.So(err, ShouldBeNil)
+			c.So(len(values), ShouldEqual, 2)
+		})
+
+		c.Convey("resolves concurrent writes with a conflict resolver", FailureHalts, func(c C) {
+			openStores := func(name string, resolver orbitdb.ConflictResolver) (orbitdb.KeyValueStore, orbitdb.KeyValueStore) {
+				db1, err := orbitdb1.KeyValue(ctx, name, &orbitdb.CreateDBOptions{
+					AccessController: ac,
+					Replicate:        &replicate,
+					ConflictResolver: resolver,
+				})
+				c.So(err, ShouldBeNil)
+
+				db2, err := orbitdb2.KeyValue(ctx, db1.Address().String(), &orbitdb.CreateDBOptions{
+					AccessController: ac,
+					Replicate:        &replicate,
+					ConflictResolver: resolver,
+				})
+				c.So(err, ShouldBeNil)
+
+				return db1, db2
+			}
+
+			c.Convey("keeps the value of the writer with the highest priority", FailureHalts, func(c C) {
+				db1, db2 := openStores("writer-priority", conflict.WriterPriority([]string{orbitdb1.Identity().ID}))
+				defer db1.Close()
+				defer db2.Close()
+
+				_, err := db1.Put(ctx, "key", []byte("from db1"))
+				c.So(err, ShouldBeNil)
+
+				_, err = db2.Put(ctx, "key", []byte("from db2"))
+				c.So(err, ShouldBeNil)
+
+				err = db2.Sync(ctx, db1.OpLog().Heads().Slice())
+				c.So(err, ShouldBeNil)
+
+				<-time.After(time.Millisecond * 300)
+
+				value, err := db2.Get(ctx, "key")
+				c.So(err, ShouldBeNil)
+				c.So(string(value), ShouldEqual, "from db1")
+
+				c.Convey("lets later writes of any writer replace the value", FailureHalts, func(c C) {
+					_, err := db2.Put(ctx, "key", []byte("after merge"))
+					c.So(err, ShouldBeNil)
+
+					value, err := db2.Get(ctx, "key")
+					c.So(err, ShouldBeNil)
+					c.So(string(value), ShouldEqual, "after merge")
+				})
+			})
+
+			c.Convey("merges concurrent values", FailureHalts, func(c C) {
+				db1, db2 := openStores("merge", conflict.Merge(func(_ string, values [][]byte) []byte {
+					var parts []string
+					for _, v := range values {
+						parts = append(parts, string(v))
+					}
+
+					sort.Strings(parts)
+
+					return []byte(strings.Join(parts, ","))
+				}))
+				defer db1.Close()
+				defer db2.Close()
+
+				_, err := db1.Put(ctx, "key", []byte("a"))
+				c.So(err, ShouldBeNil)
+
+				_, err = db2.Put(ctx, "key", []byte("b"))
+				c.So(err, ShouldBeNil)
+
+				err = db1.Sync(ctx, db2.OpLog().Heads().Slice())
+				c.So(err, ShouldBeNil)
+
+				<-time.After(time.Millisecond * 300)
+
+				value, err := db1.Get(ctx, "key")
+				c.So(err, ShouldBeNil)
+				c.So(string(value), ShouldEqual, "a,b")
+			})
+		})
+
+		c.Convey("resolves concurrent document writes with a conflict resolver", FailureHalts, func(c C) {
+			resolver := conflict.WriterPriority([]string{orbitdb1.Identity().ID})
+
+			docs1, err := orbitdb1.Docs(ctx, "docs-writer-priority", &orbitdb.CreateDBOptions{
+				AccessController: ac,
+				Replicate:        &replicate,
+				ConflictResolver: resolver,
+			})
+			c.So(err, ShouldBeNil)
+			defer docs1.Close()
+
+			docs2, err := orbitdb2.Docs(ctx, docs1.Address().String(), &orbitdb.CreateDBOptions{
+				AccessController: ac,
+				Replicate:        &replicate,
+				ConflictResolver: resolver,
+			})
+			c.So(err, ShouldBeNil)
+			defer docs2.Close()
+
+			_, err = docs1.Put(ctx, map[string]interface{}{"_id": "doc", "from": "docs1"})
+			c.So(err, ShouldBeNil)
+
+			op, err := docs2.Put(ctx, map[string]interface{}{"_id": "doc", "from": "docs2"})
+			c.So(err, ShouldBeNil)
+			c.So(op.GetTimestamp(), ShouldNotBeNil)
+
+			err = docs2.Sync(ctx, docs1.OpLog().Heads().Slice())
+			c.So(err, ShouldBeNil)
+
+			<-time.After(time.Millisecond * 300)
+
+			doc, err := docs2.Get(ctx, "doc")
+			c.So(err, ShouldBeNil)
+			c.So(doc["from"], ShouldEqual, "docs1")
+		})
+
+		c.Convey("only records timestamps when a conflict resolver is set", FailureHalts, func(c C) {
+			op, err := db1.Put(ctx, "key", []byte("hello"))
+			c.So(err, ShouldBeNil)
+			c.So(op.GetTimestamp(), ShouldBeNil)
+		})
+
+		c.Convey("rejects conflict resolvers on stores which don't support them", FailureHalts, func(c C) {
+			_, err := orbitdb1.Log(ctx, "log-resolver", &orbitdb.CreateDBOptions{ConflictResolver: conflict.Lamport})
+			c.So(err, ShouldNotBeNil)
+
+			_, err = orbitdb1.Counter(ctx, "counter-resolver", &orbitdb.CreateDBOptions{ConflictResolver: conflict.Lamport})
+			c.So(err, ShouldNotBeNil)
+		})
+
+		TeardownNetwork()
+	})
+}
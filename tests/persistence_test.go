@@ -1,7 +1,10 @@
 package tests
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path"
@@ -9,10 +12,14 @@ import (
 	"testing"
 	"time"
 
+	ipfslog "berty.tech/go-ipfs-log"
 	orbitdb "berty.tech/go-orbit-db"
+	"berty.tech/go-orbit-db/cache/cacheleveldown"
 	"berty.tech/go-orbit-db/events"
 	"berty.tech/go-orbit-db/stores"
 	"berty.tech/go-orbit-db/stores/operation"
+	"github.com/ipfs/go-datastore"
+	files "github.com/ipfs/go-ipfs-files"
 	. "github.com/smartystreets/goconvey/convey"
 )
 
@@ -106,7 +113,7 @@ func TestPersistence(t *testing.T) {
 				db, err := orbitdb1.Log(ctx, address.String(), nil)
 				c.So(err, ShouldBeNil)
 
-				err = db.Load(ctx, -1) // don't wait for load to finish
+				err = db.Load(ctx, infinity) // don't wait for load to finish
 				c.So(err, ShouldBeNil)
 
 				err = db.Close()
@@ -138,6 +145,69 @@ func TestPersistence(t *testing.T) {
 				}
 			})
 
+			c.Convey("restores a persisted index and applies newer entries", FailureHalts, func(c C) {
+				kv, err := orbitdb1.KeyValue(ctx, fmt.Sprintf("%s-kv", dbName), nil)
+				c.So(err, ShouldBeNil)
+
+				kvAddress := kv.Address().String()
+
+				for i := 0; i < entryCount; i++ {
+					_, err := kv.Put(ctx, fmt.Sprintf("key%d", i%10), []byte(fmt.Sprintf("hello%d", i)))
+					c.So(err, ShouldBeNil)
+				}
+
+				// the index is saved on close
+				err = kv.Close()
+				c.So(err, ShouldBeNil)
+
+				kv, err = orbitdb1.KeyValue(ctx, kvAddress, nil)
+				c.So(err, ShouldBeNil)
+
+				err = kv.Load(ctx, infinity)
+				c.So(err, ShouldBeNil)
+
+				_, err = kv.Put(ctx, "key0", []byte("updated"))
+				c.So(err, ShouldBeNil)
+
+				// the index isn't saved, newer entries must be applied on load
+				kv, err = orbitdb1.KeyValue(ctx, kvAddress, nil)
+				c.So(err, ShouldBeNil)
+
+				watchCtx, watchCancel := context.WithCancel(ctx)
+				defer watchCancel()
+
+				changes := kv.Watch(watchCtx, "")
+
+				err = kv.Load(ctx, infinity)
+				c.So(err, ShouldBeNil)
+
+				// only the entry written after the saved state is applied
+				applied := 0
+				for done := false; !done; {
+					select {
+					case <-changes:
+						applied++
+					case <-time.After(time.Millisecond * 300):
+						done = true
+					}
+				}
+
+				c.So(applied, ShouldEqual, 1)
+
+				c.So(len(kv.All()), ShouldEqual, 10)
+
+				value, err := kv.Get(ctx, "key0")
+				c.So(err, ShouldBeNil)
+				c.So(string(value), ShouldEqual, "updated")
+
+				value, err = kv.Get(ctx, "key9")
+				c.So(err, ShouldBeNil)
+				c.So(string(value), ShouldEqual, fmt.Sprintf("hello%d", entryCount-6))
+
+				err = kv.Drop()
+				c.So(err, ShouldBeNil)
+			})
+
 			c.Convey("loading a database emits 'ready' event", FailureHalts, func(c C) {
 				db, err := orbitdb1.Log(ctx, address.String(), nil)
 				c.So(err, ShouldBeNil)
@@ -146,7 +216,7 @@ func TestPersistence(t *testing.T) {
 				wg.Add(1)
 				var items []operation.Operation
 
-				go db.Subscribe(ctx, func(evt events.Event) {
+				db.Subscribe(ctx, func(evt events.Event) {
 					switch evt.(type) {
 					case *stores.EventReady:
 						items, err = db.List(ctx, &orbitdb.StreamOptions{Amount: &infinity})
@@ -195,6 +265,45 @@ func TestPersistence(t *testing.T) {
 				})
 			})
 
+			c.Convey("load from snapshot with entries larger than 64KB", FailureHalts, func(c C) {
+				for _, compression := range []bool{false, true} {
+					db, err := orbitdb1.Log(ctx, fmt.Sprintf("large-snapshot-%t", compression), &orbitdb.CreateDBOptions{
+						SnapshotCompression: &compression,
+					})
+					c.So(err, ShouldBeNil)
+
+					address := db.Address().String()
+					value := bytes.Repeat([]byte("a"), 100*1024)
+
+					_, err = db.Add(ctx, value)
+					c.So(err, ShouldBeNil)
+
+					_, err = db.Add(ctx, []byte("hello"))
+					c.So(err, ShouldBeNil)
+
+					_, err = db.SaveSnapshot(ctx)
+					c.So(err, ShouldBeNil)
+
+					err = db.Close()
+					c.So(err, ShouldBeNil)
+
+					db, err = orbitdb1.Log(ctx, address, nil)
+					c.So(err, ShouldBeNil)
+
+					err = db.LoadFromSnapshot(ctx)
+					c.So(err, ShouldBeNil)
+
+					items, err := db.List(ctx, &orbitdb.StreamOptions{Amount: &infinity})
+					c.So(err, ShouldBeNil)
+					c.So(len(items), ShouldEqual, 2)
+					c.So(items[0].GetValue(), ShouldResemble, value)
+					c.So(string(items[1].GetValue()), ShouldEqual, "hello")
+
+					err = db.Drop()
+					c.So(err, ShouldBeNil)
+				}
+			})
+
 			c.Convey("load from snapshot", FailureHalts, func(c C) {
 				dbName := time.Now().String()
 				var entryArr []operation.Operation
@@ -261,6 +370,46 @@ func TestPersistence(t *testing.T) {
 					}
 				})
 
+				c.Convey("loads database from a v1 snapshot", FailureHalts, func(c C) {
+					db, err := orbitdb1.Log(ctx, address, nil)
+					c.So(err, ShouldBeNil)
+
+					err = db.Load(ctx, infinity)
+					c.So(err, ShouldBeNil)
+
+					snapshot, err := writeV1Snapshot(db.OpLog(), db.Type())
+					c.So(err, ShouldBeNil)
+
+					snapshotPath, err := db1IPFS.Unixfs().Add(ctx, files.NewBytesFile(snapshot))
+					c.So(err, ShouldBeNil)
+
+					err = db.Drop()
+					c.So(err, ShouldBeNil)
+
+					db, err = orbitdb1.Log(ctx, address, nil)
+					c.So(err, ShouldBeNil)
+
+					// the store shares the cache instance opened for its address
+					cache, err := cacheleveldown.New().Load(db1Path, db.Address())
+					c.So(err, ShouldBeNil)
+
+					err = cache.Put(datastore.NewKey("snapshot"), []byte(snapshotPath.Cid().String()))
+					c.So(err, ShouldBeNil)
+
+					err = db.LoadFromSnapshot(ctx)
+					c.So(err, ShouldBeNil)
+
+					items, err := db.List(ctx, &orbitdb.StreamOptions{Amount: &infinity})
+					c.So(err, ShouldBeNil)
+
+					c.So(len(items), ShouldEqual, entryCount)
+					c.So(string(items[0].GetValue()), ShouldEqual, "hello0")
+					c.So(string(items[entryCount-1].GetValue()), ShouldEqual, fmt.Sprintf("hello%d", entryCount-1))
+
+					err = db.Close()
+					c.So(err, ShouldBeNil)
+				})
+
 				c.Convey("throws an error when trying to load a missing snapshot", FailureHalts, func(c C) {
 					db, err := orbitdb1.Log(ctx, address, nil)
 					c.So(err, ShouldBeNil)
@@ -276,6 +425,46 @@ func TestPersistence(t *testing.T) {
 					c.So(err.Error(), ShouldContainSubstring, "not found")
 				})
 
+				c.Convey("loads database from a snapshot CID", FailureHalts, func(c C) {
+					db, err := orbitdb1.Log(ctx, address, nil)
+					c.So(err, ShouldBeNil)
+
+					err = db.LoadFromSnapshot(ctx)
+					c.So(err, ShouldBeNil)
+
+					snapshotCID, err := db.SaveSnapshot(ctx)
+					c.So(err, ShouldBeNil)
+
+					err = db.Drop()
+					c.So(err, ShouldBeNil)
+
+					db, err = orbitdb1.Log(ctx, address, nil)
+					c.So(err, ShouldBeNil)
+
+					err = db.LoadFromSnapshotCID(ctx, snapshotCID)
+					c.So(err, ShouldBeNil)
+
+					items, err := db.List(ctx, &orbitdb.StreamOptions{Amount: &infinity})
+					c.So(err, ShouldBeNil)
+
+					c.So(len(items), ShouldEqual, entryCount)
+					c.So(string(items[0].GetValue()), ShouldEqual, "hello0")
+
+					c.Convey("throws an error when the snapshot belongs to another log", FailureHalts, func(c C) {
+						other, err := orbitdb1.Log(ctx, fmt.Sprintf("%s-other", dbName), nil)
+						c.So(err, ShouldBeNil)
+
+						defer other.Drop()
+
+						err = other.LoadFromSnapshotCID(ctx, snapshotCID)
+						c.So(err, ShouldNotBeNil)
+						c.So(err.Error(), ShouldContainSubstring, "doesn't match")
+					})
+
+					err = db.Close()
+					c.So(err, ShouldBeNil)
+				})
+
 				c.Convey("loading a database emits 'ready' event", FailureHalts, func(c C) {
 					// TODO
 				})
@@ -294,3 +483,43 @@ func TestPersistence(t *testing.T) {
 		TeardownNetwork()
 	})
 }
+
+// writeV1Snapshot Encodes a log using the v1 snapshot format, the header and
+// the entries JSON being prefixed with their length as an uint16
+func writeV1Snapshot(log ipfslog.Log, storeType string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	writeFrame := func(v interface{}) error {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		size := make([]byte, 2)
+		binary.BigEndian.PutUint16(size, uint16(len(data)))
+
+		buf.Write(size)
+		buf.Write(data)
+
+		return nil
+	}
+
+	header := map[string]interface{}{
+		"id":    log.GetID(),
+		"heads": log.Heads().Slice(),
+		"size":  log.Values().Len(),
+		"type":  storeType,
+	}
+
+	if err := writeFrame(header); err != nil {
+		return nil, err
+	}
+
+	for _, e := range log.Values().Slice() {
+		if err := writeFrame(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
@@ -95,7 +95,7 @@ func TestReplicateAutomatically(t *testing.T) {
 			defer cancel()
 
 			hasAllResults := false
-			go db2.Subscribe(ctx, func(evt events.Event) {
+			db2.Subscribe(ctx, func(evt events.Event) {
 				switch evt.(type) {
 				case *stores.EventReplicated:
 					infinity := -1
@@ -149,7 +149,7 @@ func TestReplicateAutomatically(t *testing.T) {
 
 			infinity := -1
 
-			go db4.Subscribe(ctx, func(event events.Event) {
+			db4.Subscribe(ctx, func(event events.Event) {
 				switch event.(type) {
 				case *stores.EventReplicated:
 					c.So("", ShouldEqual, "Should not happen")
@@ -157,7 +157,7 @@ func TestReplicateAutomatically(t *testing.T) {
 				}
 			})
 
-			go db2.Subscribe(ctx, func(event events.Event) {
+			db2.Subscribe(ctx, func(event events.Event) {
 				switch event.(type) {
 				case *stores.EventReplicateProgress:
 					e := event.(*stores.EventReplicateProgress)
@@ -0,0 +1,301 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	orbitdb "berty.tech/go-orbit-db"
+	"berty.tech/go-orbit-db/accesscontroller"
+	"berty.tech/go-orbit-db/stores"
+	"berty.tech/go-orbit-db/stores/operation"
+	"berty.tech/go-orbit-db/stores/schema"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const userSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"type": "integer", "minimum": 0},
+		"tags": {"type": "array", "items": {"enum": ["admin", "user"]}}
+	},
+	"required": ["name"],
+	"additionalProperties": false
+}`
+
+func TestSchema(t *testing.T) {
+	Convey("orbit-db - JSON Schema", t, FailureHalts, func(c C) {
+		s, err := schema.Parse([]byte(userSchema))
+		c.So(err, ShouldBeNil)
+
+		c.Convey("accepts matching values", FailureHalts, func(c C) {
+			for _, value := range []string{
+				`{"name": "alice"}`,
+				`{"name": "bob", "age": 42, "tags": ["admin"]}`,
+			} {
+				c.So(s.ValidateJSON([]byte(value)), ShouldBeNil)
+			}
+		})
+
+		c.Convey("rejects invalid values", FailureHalts, func(c C) {
+			for _, value := range []string{
+				`not json`,
+				`"alice"`,
+				`{}`,
+				`{"name": ""}`,
+				`{"name": "alice", "age": 4.2}`,
+				`{"name": "alice", "age": -1}`,
+				`{"name": "alice", "tags": ["root"]}`,
+				`{"name": "alice", "email": "alice@example.com"}`,
+			} {
+				c.So(s.ValidateJSON([]byte(value)), ShouldNotBeNil)
+			}
+		})
+
+		c.Convey("applies each supported keyword", FailureHalts, func(c C) {
+			for _, tc := range []struct {
+				schema  string
+				valid   []string
+				invalid []string
+			}{
+				{`true`, []string{`null`, `1`, `{}`}, nil},
+				{`false`, nil, []string{`null`, `1`, `{}`}},
+				{`{"type": "null"}`, []string{`null`}, []string{`0`, `false`, `""`}},
+				{`{"type": "boolean"}`, []string{`true`, `false`}, []string{`null`, `0`, `"true"`}},
+				{`{"type": "object"}`, []string{`{}`, `{"a": 1}`}, []string{`[]`, `null`, `"{}"`}},
+				{`{"type": "array"}`, []string{`[]`, `[1, "a"]`}, []string{`{}`, `"[]"`}},
+				{`{"type": "number"}`, []string{`1`, `1.5`, `-2`}, []string{`"1"`, `null`}},
+				{`{"type": "integer"}`, []string{`1`, `-2`, `1.0`}, []string{`1.5`, `"1"`}},
+				{`{"type": "string"}`, []string{`""`, `"a"`}, []string{`1`, `null`, `["a"]`}},
+				{`{"type": ["string", "null"]}`, []string{`"a"`, `null`}, []string{`1`, `{}`}},
+				{`{"enum": [1, "a", null, {"b": [2]}]}`, []string{`1`, `"a"`, `null`, `{"b": [2]}`}, []string{`2`, `"b"`, `{"b": [3]}`}},
+				{`{"const": {"a": 1}}`, []string{`{"a": 1}`}, []string{`{"a": 2}`, `{"a": 1, "b": 2}`, `1`}},
+				{`{"properties": {"a": {"type": "string"}}}`, []string{`{}`, `{"a": "x"}`, `{"b": 1}`, `1`}, []string{`{"a": 1}`}},
+				{`{"required": ["a", "b"]}`, []string{`{"a": 1, "b": null}`, `[]`}, []string{`{}`, `{"a": 1}`}},
+				{`{"properties": {"a": {}}, "additionalProperties": false}`, []string{`{}`, `{"a": 1}`}, []string{`{"b": 1}`}},
+				{`{"properties": {"a": {}}, "additionalProperties": {"type": "integer"}}`, []string{`{"a": "x", "b": 1}`}, []string{`{"b": "x"}`}},
+				{`{"items": {"type": "integer"}}`, []string{`[]`, `[1, 2]`, `"a"`}, []string{`[1, "a"]`, `[1.5]`}},
+				{`{"minItems": 2}`, []string{`[1, 2]`, `[1, 2, 3]`, `{}`}, []string{`[]`, `[1]`}},
+				{`{"maxItems": 1}`, []string{`[]`, `[1]`}, []string{`[1, 2]`}},
+				{`{"minLength": 2}`, []string{`"ab"`, `"été"`, `1`}, []string{`""`, `"a"`}},
+				{`{"maxLength": 2}`, []string{`""`, `"éé"`}, []string{`"abc"`}},
+				{`{"pattern": "^[a-z]+$"}`, []string{`"abc"`, `1`}, []string{`"ABC"`, `""`, `"ab1"`}},
+				{`{"pattern": "b"}`, []string{`"abc"`}, []string{`"ac"`}},
+				{`{"minimum": 1}`, []string{`1`, `2.5`, `"0"`}, []string{`0.9`, `-1`}},
+				{`{"maximum": 1}`, []string{`1`, `-3`}, []string{`1.1`}},
+				{`{"exclusiveMinimum": 1}`, []string{`1.1`, `2`}, []string{`1`, `0`}},
+				{`{"exclusiveMaximum": 1}`, []string{`0.9`, `0`}, []string{`1`, `2`}},
+				{`{"allOf": [{"minimum": 1}, {"maximum": 3}]}`, []string{`1`, `3`}, []string{`0`, `4`}},
+				{`{"anyOf": [{"type": "string"}, {"minimum": 3}]}`, []string{`"a"`, `3`}, []string{`2`, `null`}},
+				{`{"oneOf": [{"type": "integer"}, {"minimum": 3}]}`, []string{`1`, `3.5`}, []string{`3`, `2.5`}},
+				{`{"not": {"type": "string"}}`, []string{`1`, `null`}, []string{`"a"`}},
+				{`{"title": "t", "description": "d", "default": 1, "examples": [1], "$comment": "c"}`, []string{`1`, `"a"`}, nil},
+			} {
+				s, err := schema.Parse([]byte(tc.schema))
+				c.So(err, ShouldBeNil)
+
+				for _, value := range tc.valid {
+					c.So(s.ValidateJSON([]byte(value)), ShouldBeNil)
+				}
+
+				for _, value := range tc.invalid {
+					c.So(s.ValidateJSON([]byte(value)), ShouldNotBeNil)
+				}
+			}
+		})
+
+		c.Convey("rejects schemas using unsupported keywords", FailureHalts, func(c C) {
+			for _, doc := range []string{
+				`{"type": "string", "format": "email"}`,
+				`{"type": "array", "uniqueItems": true}`,
+				`{"patternProperties": {"^x-": {"type": "string"}}}`,
+				`{"properties": {"name": {"minProperties": 1}}}`,
+				`{"dependencies": {"a": ["b"]}}`,
+				`{"if": {"required": ["a"]}, "then": {"required": ["b"]}}`,
+			} {
+				_, err := schema.Parse([]byte(doc))
+				c.So(err, ShouldNotBeNil)
+			}
+
+			_, err := schema.Parse([]byte(`{"title": "user", "description": "a user", "type": "object"}`))
+			c.So(err, ShouldBeNil)
+		})
+
+		c.Convey("rejects invalid schemas", FailureHalts, func(c C) {
+			for _, doc := range []string{
+				`[]`,
+				`{"type": "text"}`,
+				`{"minLength": -1}`,
+				`{"pattern": "("}`,
+				`{"$ref": "#/definitions/user"}`,
+			} {
+				_, err := schema.Parse([]byte(doc))
+				c.So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func TestStoreSchema(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	defer cancel()
+
+	dbPath := "./orbitdb/tests/schema"
+
+	_, ipfs := MakeIPFS(ctx, t)
+
+	defer os.RemoveAll(dbPath)
+
+	Convey("orbit-db - Store schema", t, FailureHalts, func(c C) {
+		err := os.RemoveAll(dbPath)
+		c.So(err, ShouldBeNil)
+
+		dbPath1 := path.Join(dbPath, "1")
+		dbPath2 := path.Join(dbPath, "2")
+
+		orbitdb1, err := orbitdb.NewOrbitDB(ctx, ipfs, &orbitdb.NewOrbitDBOptions{Directory: &dbPath1})
+		c.So(err, ShouldBeNil)
+		defer orbitdb1.Close()
+
+		orbitdb2, err := orbitdb.NewOrbitDB(ctx, ipfs, &orbitdb.NewOrbitDBOptions{Directory: &dbPath2})
+		c.So(err, ShouldBeNil)
+		defer orbitdb2.Close()
+
+		ac := &accesscontroller.CreateAccessControllerOptions{
+			Access: map[string][]string{
+				"write": {
+					orbitdb1.Identity().ID,
+					orbitdb2.Identity().ID,
+				},
+			},
+		}
+
+		replicate := false
+
+		db1, err := orbitdb1.KeyValue(ctx, "users", &orbitdb.CreateDBOptions{
+			AccessController: ac,
+			Replicate:        &replicate,
+			Schema:           []byte(userSchema),
+		})
+		c.So(err, ShouldBeNil)
+		defer db1.Close()
+
+		c.Convey("commits to the schema in the address", FailureHalts, func(c C) {
+			addr, err := orbitdb1.DetermineAddress(ctx, "users", "keyvalue", &orbitdb.DetermineAddressOptions{AccessController: ac})
+			c.So(err, ShouldBeNil)
+			c.So(addr.String(), ShouldNotEqual, db1.Address().String())
+
+			_, err = orbitdb1.KeyValue(ctx, "invalid", &orbitdb.CreateDBOptions{Schema: []byte(`{"type": "text"}`)})
+			c.So(err, ShouldNotBeNil)
+		})
+
+		c.Convey("only accepts a schema for key-value and document stores", FailureHalts, func(c C) {
+			_, err := orbitdb1.Log(ctx, "events", &orbitdb.CreateDBOptions{Schema: []byte(userSchema)})
+			c.So(err, ShouldNotBeNil)
+
+			_, err = orbitdb1.Counter(ctx, "counter", &orbitdb.CreateDBOptions{Schema: []byte(userSchema)})
+			c.So(err, ShouldNotBeNil)
+
+			_, err = orbitdb1.DetermineAddress(ctx, "events", "eventlog", &orbitdb.DetermineAddressOptions{Schema: []byte(userSchema)})
+			c.So(err, ShouldNotBeNil)
+
+			docs, err := orbitdb1.Docs(ctx, "documents", &orbitdb.CreateDBOptions{
+				Replicate: &replicate,
+				Schema:    []byte(`{"type": "object", "required": ["_id"]}`),
+			})
+			c.So(err, ShouldBeNil)
+			defer docs.Close()
+		})
+
+		c.Convey("rejects invalid local writes", FailureHalts, func(c C) {
+			_, err := db1.Put(ctx, "alice", []byte(`{"name": "alice"}`))
+			c.So(err, ShouldBeNil)
+
+			_, err = db1.Put(ctx, "bob", []byte(`{"age": 42}`))
+			c.So(err, ShouldNotBeNil)
+
+			_, err = db1.Delete(ctx, "alice")
+			c.So(err, ShouldBeNil)
+		})
+
+		c.Convey("rejects and reports invalid replicated entries", FailureHalts, func(c C) {
+			db2, err := orbitdb2.KeyValue(ctx, db1.Address().String(), &orbitdb.CreateDBOptions{
+				AccessController: ac,
+				Replicate:        &replicate,
+			})
+			c.So(err, ShouldBeNil)
+			defer db2.Close()
+
+			subCtx, subCancel := context.WithCancel(ctx)
+			defer subCancel()
+
+			invalid := stores.SubscribeInvalidEntry(subCtx, db2)
+
+			// bypasses the hooks of db1, as a peer ignoring the schema would
+			key := "bob"
+			payload, err := operation.NewOperation(&key, "PUT", []byte(`{"age": 42}`)).Marshal()
+			c.So(err, ShouldBeNil)
+
+			e, err := db1.OpLog().Append(ctx, payload, 1)
+			c.So(err, ShouldBeNil)
+
+			err = db2.Sync(ctx, db1.OpLog().Heads().Slice())
+			c.So(err, ShouldBeNil)
+
+			var evt *stores.EventInvalidEntry
+			select {
+			case evt = <-invalid:
+			case <-time.After(time.Second * 5):
+			}
+
+			c.So(evt, ShouldNotBeNil)
+			c.So(evt.Entry.GetHash().String(), ShouldEqual, e.GetHash().String())
+			c.So(evt.Err, ShouldNotBeNil)
+
+			value, err := db2.Get(ctx, "bob")
+			c.So(err, ShouldBeNil)
+			c.So(value, ShouldBeNil)
+		})
+
+		c.Convey("rejects and reports CAR files containing invalid entries", FailureHalts, func(c C) {
+			key := "bob"
+			payload, err := operation.NewOperation(&key, "PUT", []byte(`{"age": 42}`)).Marshal()
+			c.So(err, ShouldBeNil)
+
+			e, err := db1.OpLog().Append(ctx, payload, 1)
+			c.So(err, ShouldBeNil)
+
+			buf := &bytes.Buffer{}
+			c.So(db1.ExportCAR(ctx, buf), ShouldBeNil)
+
+			db2, err := orbitdb2.KeyValue(ctx, db1.Address().String(), &orbitdb.CreateDBOptions{
+				AccessController: ac,
+				Replicate:        &replicate,
+			})
+			c.So(err, ShouldBeNil)
+			defer db2.Close()
+
+			subCtx, subCancel := context.WithCancel(ctx)
+			defer subCancel()
+
+			invalid := stores.SubscribeInvalidEntry(subCtx, db2)
+
+			err = db2.ImportCAR(ctx, bytes.NewReader(buf.Bytes()))
+			c.So(err, ShouldNotBeNil)
+
+			var evt *stores.EventInvalidEntry
+			select {
+			case evt = <-invalid:
+			case <-time.After(time.Second * 5):
+			}
+
+			c.So(evt, ShouldNotBeNil)
+			c.So(evt.Entry.GetHash().String(), ShouldEqual, e.GetHash().String())
+			c.So(db2.OpLog().Values().Len(), ShouldEqual, 0)
+		})
+	})
+}
@@ -23,7 +23,7 @@ func init() {
 var TestNetwork mocknet.Mocknet
 
 // MakeIPFS Creates a new IPFS node for testing purposes
-func MakeIPFS(ctx context.Context, t *testing.T) (*ipfsCore.IpfsNode, iface.CoreAPI) {
+func MakeIPFS(ctx context.Context, t testing.TB) (*ipfsCore.IpfsNode, iface.CoreAPI) {
 	if TestNetwork == nil {
 		TestNetwork = mocknet.New(ctx)
 	}
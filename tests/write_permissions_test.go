@@ -180,7 +180,7 @@ func TestWritePermissions(t *testing.T) {
 				defer db2.Close()
 
 				subCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
-				go db1.Subscribe(subCtx, func(evt events.Event) {
+				db1.Subscribe(subCtx, func(evt events.Event) {
 					switch evt.(type) {
 					case *stores.EventReplicated:
 						c.So("this", ShouldEqual, "should not occur")
@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	ipfslogio "berty.tech/go-ipfs-log/io"
+	"github.com/ipfs/go-cid"
+	cbornode "github.com/ipfs/go-ipld-cbor"
+	coreapi "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	"github.com/ipfs/interface-go-ipfs-core/path"
+	"github.com/pkg/errors"
+	"github.com/polydawn/refmt/obj/atlas"
+)
+
+const carVersion = 1
+
+// maxCARSectionSize is the maximum size of a block section accepted when
+// reading a CAR file
+const maxCARSectionSize = 4 << 20
+
+// carHeader defines the header of a CARv1 file
+type carHeader struct {
+	Roots   []cid.Cid
+	Version uint64
+}
+
+// atlasCARHeader defines how a CAR header is serialized
+var atlasCARHeader = atlas.BuildEntry(carHeader{}).
+	StructMap().
+	AddField("Roots", atlas.StructMapEntry{SerialName: "roots"}).
+	AddField("Version", atlas.StructMapEntry{SerialName: "version"}).
+	Complete()
+
+// WriteCAR writes the given blocks as a CARv1 file, blocks are fetched from IPFS
+func WriteCAR(ctx context.Context, ipfs coreapi.CoreAPI, w io.Writer, roots []cid.Cid, blocks []cid.Cid) error {
+	header, err := cbornode.DumpObject(&carHeader{Roots: roots, Version: carVersion})
+	if err != nil {
+		return errors.Wrap(err, "unable to serialize CAR header")
+	}
+
+	if err := writeCARSection(w, header); err != nil {
+		return errors.Wrap(err, "unable to write CAR header")
+	}
+
+	seen := map[string]struct{}{}
+
+	for _, c := range blocks {
+		if _, ok := seen[c.KeyString()]; ok {
+			continue
+		}
+
+		seen[c.KeyString()] = struct{}{}
+
+		r, err := ipfs.Block().Get(ctx, path.IpfsPath(c))
+		if err != nil {
+			return errors.Wrap(err, "unable to get block")
+		}
+
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			return errors.Wrap(err, "unable to read block")
+		}
+
+		if err := writeCARSection(w, append(c.Bytes(), data...)); err != nil {
+			return errors.Wrap(err, "unable to write CAR block")
+		}
+	}
+
+	return nil
+}
+
+// ImportCAR adds the blocks of a CARv1 file to IPFS and returns its roots
+func ImportCAR(ctx context.Context, ipfs coreapi.CoreAPI, r io.Reader) ([]cid.Cid, error) {
+	br := bufio.NewReader(r)
+
+	headerBytes, err := readCARSection(br)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read CAR header")
+	}
+
+	header := &carHeader{}
+	if err := cbornode.DecodeInto(headerBytes, header); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal CAR header")
+	}
+
+	if header.Version != carVersion {
+		return nil, errors.Errorf("unsupported CAR version %d", header.Version)
+	}
+
+	for {
+		section, err := readCARSection(br)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errors.Wrap(err, "unable to read CAR block")
+		}
+
+		n, c, err := cid.CidFromBytes(section)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse block CID")
+		}
+
+		if err := putBlock(ctx, ipfs, c, section[n:]); err != nil {
+			return nil, err
+		}
+	}
+
+	return header.Roots, nil
+}
+
+// ManifestBlocks returns the CIDs of the blocks needed to open a store
+// without network access, ie. its manifest, its schema and its access
+// controller manifest
+func ManifestBlocks(ctx context.Context, ipfs coreapi.CoreAPI, manifestCID cid.Cid) ([]cid.Cid, error) {
+	node, err := ipfslogio.ReadCBOR(ctx, ipfs, manifestCID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to fetch database manifest")
+	}
+
+	manifest := &Manifest{}
+	if err := cbornode.DecodeInto(node.RawData(), manifest); err != nil {
+		return nil, errors.Wrap(err, "unable to unmarshal manifest")
+	}
+
+	blocks := []cid.Cid{manifestCID}
+
+	if manifest.Schema != "" {
+		schemaCID, err := cid.Decode(strings.TrimPrefix(manifest.Schema, "/ipfs/"))
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to parse schema CID")
+		}
+
+		blocks = append(blocks, schemaCID)
+	}
+
+	acCID, err := cid.Decode(strings.TrimPrefix(manifest.AccessController, "/ipfs/"))
+	if err != nil {
+		// access controllers skipping their manifest have no block to export
+		return blocks, nil
+	}
+
+	return collectDAG(ctx, ipfs, acCID, blocks, map[string]struct{}{})
+}
+
+// collectDAG appends the given CID and all the CIDs it links to
+func collectDAG(ctx context.Context, ipfs coreapi.CoreAPI, c cid.Cid, blocks []cid.Cid, seen map[string]struct{}) ([]cid.Cid, error) {
+	if _, ok := seen[c.KeyString()]; ok {
+		return blocks, nil
+	}
+
+	seen[c.KeyString()] = struct{}{}
+
+	node, err := ipfs.Dag().Get(ctx, c)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get node")
+	}
+
+	blocks = append(blocks, c)
+
+	for _, l := range node.Links() {
+		blocks, err = collectDAG(ctx, ipfs, l.Cid, blocks, seen)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return blocks, nil
+}
+
+func putBlock(ctx context.Context, ipfs coreapi.CoreAPI, c cid.Cid, data []byte) error {
+	format, ok := cid.CodecToStr[c.Type()]
+	if !ok {
+		return errors.Errorf("unsupported block codec %d", c.Type())
+	}
+
+	if c.Version() == 0 {
+		format = "v0"
+	}
+
+	prefix := c.Prefix()
+
+	// checked before the block is stored, so tampered blocks never reach IPFS
+	sum, err := prefix.Sum(data)
+	if err != nil {
+		return errors.Wrap(err, "unable to hash block")
+	}
+
+	if !sum.Equals(c) {
+		return errors.Errorf("block %s doesn't match its contents", c.String())
+	}
+
+	if _, err := ipfs.Block().Put(ctx, bytes.NewReader(data), options.Block.Format(format), options.Block.Hash(prefix.MhType, prefix.MhLength)); err != nil {
+		return errors.Wrap(err, "unable to put block")
+	}
+
+	return nil
+}
+
+func writeCARSection(w io.Writer, data []byte) error {
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(buf, uint64(len(data)))
+
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(data)
+
+	return err
+}
+
+func readCARSection(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if size > maxCARSectionSize {
+		return nil, errors.Errorf("section of %d bytes exceeds maximum size", size)
+	}
+
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, errors.Wrap(err, "unexpected end of section")
+	}
+
+	return data, nil
+}
+
+func init() {
+	cbornode.RegisterCborType(atlasCARHeader)
+}
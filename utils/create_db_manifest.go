@@ -1,32 +1,50 @@
 package utils
 
 import (
+	"bytes"
 	"context"
+	"io/ioutil"
 	"path"
+	"strings"
 
 	"berty.tech/go-ipfs-log/io"
 	"github.com/ipfs/go-cid"
 	cbornode "github.com/ipfs/go-ipld-cbor"
 	coreapi "github.com/ipfs/interface-go-ipfs-core"
+	"github.com/ipfs/interface-go-ipfs-core/options"
+	ipfspath "github.com/ipfs/interface-go-ipfs-core/path"
 	"github.com/pkg/errors"
 	"github.com/polydawn/refmt/obj/atlas"
 )
 
-// Manifest defines a database manifest describing its type and access controller
+// Manifest defines a database manifest describing its type, access controller
+// and, optionally, the JSON Schema its values must match
 type Manifest struct {
 	Name             string
 	Type             string
 	AccessController string
+	Schema           string
 }
 
 // CreateDBManifest creates a new database manifest and saves it on IPFS
 func CreateDBManifest(ctx context.Context, ipfs coreapi.CoreAPI, name string, dbType string, accessControllerAddress string) (cid.Cid, error) {
+	return CreateDBManifestWithSchema(ctx, ipfs, name, dbType, accessControllerAddress, cid.Undef)
+}
+
+// CreateDBManifestWithSchema creates a new database manifest referencing the
+// given JSON Schema and saves it on IPFS, an undefined schema CID is omitted
+// so the manifest is the same as the one of CreateDBManifest
+func CreateDBManifestWithSchema(ctx context.Context, ipfs coreapi.CoreAPI, name string, dbType string, accessControllerAddress string, schema cid.Cid) (cid.Cid, error) {
 	manifest := &Manifest{
 		Name:             name,
 		Type:             dbType,
 		AccessController: path.Join("/ipfs", accessControllerAddress),
 	}
 
+	if schema.Defined() {
+		manifest.Schema = path.Join("/ipfs", schema.String())
+	}
+
 	c, err := io.WriteCBOR(ctx, ipfs, manifest)
 	if err != nil {
 		return cid.Cid{}, errors.Wrap(err, "unable to write cbor data")
@@ -41,8 +59,39 @@ var AtlasManifest = atlas.BuildEntry(Manifest{}).
 	AddField("Name", atlas.StructMapEntry{SerialName: "name"}).
 	AddField("Type", atlas.StructMapEntry{SerialName: "type"}).
 	AddField("AccessController", atlas.StructMapEntry{SerialName: "access_controller"}).
+	AddField("Schema", atlas.StructMapEntry{SerialName: "schema", OmitEmpty: true}).
 	Complete()
 
+// WriteSchema saves a JSON Schema document on IPFS as a raw block
+func WriteSchema(ctx context.Context, ipfs coreapi.CoreAPI, schema []byte) (cid.Cid, error) {
+	stat, err := ipfs.Block().Put(ctx, bytes.NewReader(schema), options.Block.Format("raw"))
+	if err != nil {
+		return cid.Cid{}, errors.Wrap(err, "unable to put schema block")
+	}
+
+	return stat.Path().Cid(), nil
+}
+
+// ReadSchema fetches the JSON Schema document referenced by a manifest
+func ReadSchema(ctx context.Context, ipfs coreapi.CoreAPI, schemaPath string) ([]byte, error) {
+	c, err := cid.Decode(strings.TrimPrefix(schemaPath, "/ipfs/"))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse schema CID")
+	}
+
+	r, err := ipfs.Block().Get(ctx, ipfspath.IpfsPath(c))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get schema block")
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to read schema block")
+	}
+
+	return data, nil
+}
+
 func init() {
 	cbornode.RegisterCborType(AtlasManifest)
 }